@@ -0,0 +1,316 @@
+package costexport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// Command is the name of the subcommand on the provider binary
+const Command = "cost-export"
+
+// Row is a single line of the cost report, one per subscription and invoice period
+type Row struct {
+	TicketID         string  `json:"ticketid"`
+	TicketNo         int     `json:"ticketno"`
+	ClarityCode      string  `json:"claritycode"`
+	SubscriptionName string  `json:"subscriptionname"`
+	InvoicePeriod    string  `json:"invoiceperiod"`
+	StartDate        string  `json:"startdate"`
+	EndDate          string  `json:"enddate"`
+	ActualCost       float64 `json:"actualcost"`
+}
+
+// csvHeader is the header line of the CSV report
+var csvHeader = []string{"ticketid", "ticketno", "claritycode", "subscriptionname", "invoiceperiod", "startdate", "enddate", "actualcost"}
+
+// envFlags maps flags to the environment variables of the matching provider
+// settings, which they default from when not given on the command line
+var envFlags = map[string]string{
+	"request-timeout":         "CLOUDPORTAL_REQUEST_TIMEOUT",
+	"max-retries":             "CLOUDPORTAL_MAX_RETRIES",
+	"retry-min-wait":          "CLOUDPORTAL_RETRY_MIN_WAIT",
+	"retry-max-wait":          "CLOUDPORTAL_RETRY_MAX_WAIT",
+	"max-concurrent-requests": "CLOUDPORTAL_MAX_CONCURRENT_REQUESTS",
+	"requests-per-second":     "CLOUDPORTAL_REQUESTS_PER_SECOND",
+}
+
+// Run executes the cost-export subcommand with the arguments following the
+// subcommand name
+func Run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	baseURL := flags.String("base-url", envDefault("CLOUDPORTAL_BASE_URL"), "Base URL of the portal API")
+	apiKey := flags.String("api-key", envDefault("CLOUDPORTAL_API_KEY"), "API key of the portal API")
+	clientID := flags.String("client-id", envDefault("CLOUDPORTAL_CLIENT_ID", "AZURE_CLIENT_ID"), "Client ID used to authenticate")
+	clientSecret := flags.String("client-secret", envDefault("CLOUDPORTAL_CLIENT_SECRET", "AZURE_CLIENT_SECRET"), "Client secret used to authenticate")
+	tenantID := flags.String("tenant-id", envDefault("CLOUDPORTAL_TENANT_ID", "AZURE_TENANT_ID"), "Tenant ID used to authenticate")
+	authMethod := flags.String("auth-method", envDefault("CLOUDPORTAL_AUTH_METHOD"), "How to authenticate: client_secret, client_certificate, managed_identity, workload_identity, azure_cli or default (default client_secret)")
+	certificatePath := flags.String("client-certificate-path", envDefault("CLOUDPORTAL_CLIENT_CERTIFICATE_PATH", "AZURE_CLIENT_CERTIFICATE_PATH"), "Path of the client certificate used by client_certificate")
+	certificatePassword := flags.String("client-certificate-password", envDefault("CLOUDPORTAL_CLIENT_CERTIFICATE_PASSWORD", "AZURE_CLIENT_CERTIFICATE_PASSWORD"), "Password of the client certificate")
+	oidcTokenFile := flags.String("oidc-token-file-path", envDefault("CLOUDPORTAL_OIDC_TOKEN_FILE_PATH", "AZURE_FEDERATED_TOKEN_FILE"), "Path of the federated OIDC token file used by workload_identity")
+	applicationIDURI := flags.String("application-id-uri", envDefault("CLOUDPORTAL_APPLICATION_ID_URI"), "Application ID URI of the portal API, defaults to the tenant ID")
+	scopes := flags.String("scopes", "", "Comma separated scopes requested for portal API tokens, overrides -application-id-uri")
+	authorityHost := flags.String("authority-host", envDefault("CLOUDPORTAL_AUTHORITY_HOST", "AZURE_AUTHORITY_HOST"), "Microsoft Entra authority: public, usgovernment, china or an https URL")
+	tokenCachePath := flags.String("token-cache-path", envDefault("CLOUDPORTAL_TOKEN_CACHE_PATH"), "File in which access tokens are kept encrypted between runs")
+	tokenCacheKey := flags.String("token-cache-key", envDefault("CLOUDPORTAL_TOKEN_CACHE_KEY"), "Passphrase the token cache file is encrypted with")
+	requestTimeout := flags.Duration("request-timeout", cloudportal.DefaultRequestTimeout, "Maximum time a single portal API call may take including its retries, 0 disables the timeout, defaults to CLOUDPORTAL_REQUEST_TIMEOUT")
+	maxRetries := flags.Int("max-retries", cloudportal.DefaultRetryConfig.MaxRetries, "How often a request failing with a network error, 429 or 5xx status is retried, defaults to CLOUDPORTAL_MAX_RETRIES")
+	retryMinWait := flags.Duration("retry-min-wait", cloudportal.DefaultRetryConfig.MinWait, "Wait before the first retry, doubled for every further retry, defaults to CLOUDPORTAL_RETRY_MIN_WAIT")
+	retryMaxWait := flags.Duration("retry-max-wait", cloudportal.DefaultRetryConfig.MaxWait, "Maximum wait between retries, defaults to CLOUDPORTAL_RETRY_MAX_WAIT")
+	maxConcurrentRequests := flags.Int("max-concurrent-requests", 0, "Maximum number of portal API requests in flight at the same time, 0 means unlimited, defaults to CLOUDPORTAL_MAX_CONCURRENT_REQUESTS")
+	requestsPerSecond := flags.Float64("requests-per-second", 0, "Maximum sustained rate of portal API requests, 0 means unlimited, defaults to CLOUDPORTAL_REQUESTS_PER_SECOND")
+	proxyURL := flags.String("proxy-url", envDefault("CLOUDPORTAL_PROXY_URL"), "Proxy for portal API and token requests, otherwise HTTPS_PROXY and NO_PROXY are honoured")
+	noProxy := flags.String("no-proxy", envDefault("CLOUDPORTAL_NO_PROXY"), "Comma separated hosts, domains and CIDRs reached without -proxy-url")
+	caCertFile := flags.String("ca-cert-file", envDefault("CLOUDPORTAL_CA_CERT_FILE"), "PEM file of root certificates trusted in addition to the system roots")
+	tlsClientCertFile := flags.String("tls-client-cert-file", envDefault("CLOUDPORTAL_TLS_CLIENT_CERT_FILE"), "PEM client certificate presented for mutual TLS")
+	tlsClientKeyFile := flags.String("tls-client-key-file", envDefault("CLOUDPORTAL_TLS_CLIENT_KEY_FILE"), "PEM private key of the TLS client certificate")
+	minTLSVersion := flags.String("min-tls-version", envDefault("CLOUDPORTAL_MIN_TLS_VERSION"), "Minimum TLS version, 1.2 or 1.3 (default 1.2)")
+	ticketIDs := flags.String("ticket-ids", "", "Comma separated ticket ids to export")
+	clarityCodes := flags.String("clarity-codes", "", "Comma separated clarity codes whose tickets are exported")
+	subscriptions := flags.String("subscriptions", "", "Comma separated subscription names to include, defaults to all")
+	from := flags.String("from", "", "Include invoice periods starting on or after this date (e.g. 2024-01 or 2024-01-15)")
+	to := flags.String("to", "", "Include invoice periods starting on or before this date (e.g. 2024-12 or 2024-12-31)")
+	format := flags.String("format", "csv", "Output format, csv or json")
+	output := flags.String("output", "", "File to write the report to, defaults to stdout")
+	debug := flags.Bool("debug", false, "Write debug information to provider-debug.log")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if err := setFlagsFromEnv(flags, envFlags); err != nil {
+		return err
+	}
+
+	if *baseURL == "" {
+		return fmt.Errorf("-base-url must be provided")
+	}
+	if *ticketIDs == "" && *clarityCodes == "" {
+		return fmt.Errorf("at least one of -ticket-ids or -clarity-codes must be provided")
+	}
+	tokenScopes, err := cloudportal.TokenScopes(*tenantID, *applicationIDURI, splitList(*scopes))
+	if err != nil {
+		return fmt.Errorf("one of -tenant-id, -application-id-uri or -scopes must be provided")
+	}
+	if *tokenCachePath != "" && *tokenCacheKey == "" {
+		return fmt.Errorf("-token-cache-key must be provided with -token-cache-path")
+	}
+	fromDate, err := parseDateFlag("from", *from)
+	if err != nil {
+		return err
+	}
+	toDate, err := parseDateFlag("to", *to)
+	if err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unsupported format %q, expected csv or json", *format)
+	}
+
+	if *debug {
+		if _, err := logger.NewLogger(true); err != nil {
+			return err
+		}
+		defer logger.Close()
+	}
+
+	transport, err := cloudportal.NewTransport(cloudportal.TransportConfig{
+		ProxyURL:       *proxyURL,
+		NoProxy:        *noProxy,
+		CACertFile:     *caCertFile,
+		CACertPEM:      envDefault("CLOUDPORTAL_CA_CERT_PEM"),
+		ClientCertFile: *tlsClientCertFile,
+		ClientKeyFile:  *tlsClientKeyFile,
+		MinTLSVersion:  *minTLSVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("error configuring transport: %s", err)
+	}
+
+	credentialConfig := cloudportal.CredentialConfig{
+		AuthMethod:                *authMethod,
+		TenantID:                  *tenantID,
+		ClientID:                  *clientID,
+		ClientSecret:              *clientSecret,
+		ClientCertificatePath:     *certificatePath,
+		ClientCertificatePassword: *certificatePassword,
+		OIDCTokenFilePath:         *oidcTokenFile,
+		AuthorityHost:             *authorityHost,
+		Transport:                 &http.Client{Transport: transport},
+	}
+	cred, err := cloudportal.NewCredential(credentialConfig)
+	if err != nil {
+		return fmt.Errorf("error creating credential: %s", err)
+	}
+	client := cloudportal.NewCloudportalAPIClient(cred, *apiKey, *baseURL, tokenScopes, *debug)
+	client.SetTransport(transport)
+	client.SetRequestTimeout(*requestTimeout)
+	client.SetRetryConfig(cloudportal.RetryConfig{
+		MaxRetries: *maxRetries,
+		MinWait:    *retryMinWait,
+		MaxWait:    *retryMaxWait,
+	})
+	client.SetRateLimit(cloudportal.RateLimitConfig{
+		MaxConcurrentRequests: *maxConcurrentRequests,
+		RequestsPerSecond:     *requestsPerSecond,
+	})
+	if *tokenCachePath != "" {
+		client.UseTokenCacheFile(*tokenCachePath, *tokenCacheKey, credentialConfig)
+	}
+
+	// Cancel outstanding requests when the export is interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	tickets, err := client.GetBillingTickets(ctx, splitList(*ticketIDs), splitList(*clarityCodes), nil)
+	if err != nil {
+		return err
+	}
+
+	rows := Rows(cloudportal.SummarizeBilling(tickets, splitList(*subscriptions)), fromDate, toDate)
+
+	var w io.Writer = stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		return WriteJSON(w, rows)
+	}
+	return WriteCSV(w, rows)
+}
+
+// Rows flattens the billing summary into report rows. Invoice periods
+// starting outside from and to, unless zero, are left out, as are periods
+// without a start date when either is given.
+func Rows(summary *cloudportal.BillingSummary, from, to time.Time) []Row {
+	var rows []Row
+	for _, item := range summary.Items {
+		for _, period := range item.InvoicePeriods {
+			if !from.IsZero() || !to.IsZero() {
+				start, ok := cloudportal.InvoicePeriodStart(period)
+				if !ok || (!from.IsZero() && start.Before(from)) || (!to.IsZero() && start.After(to)) {
+					continue
+				}
+			}
+			rows = append(rows, Row{
+				TicketID:         item.TicketID,
+				TicketNo:         item.TicketNo,
+				ClarityCode:      item.ClarityCode,
+				SubscriptionName: item.BillingItem.SubscriptionName,
+				InvoicePeriod:    period.InvoicePeriod,
+				StartDate:        period.StartDate,
+				EndDate:          period.EndDate,
+				ActualCost:       period.ActualCost,
+			})
+		}
+	}
+	return rows
+}
+
+// WriteCSV writes the rows as CSV with a header line
+func WriteCSV(w io.Writer, rows []Row) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.TicketID,
+			strconv.Itoa(row.TicketNo),
+			row.ClarityCode,
+			row.SubscriptionName,
+			row.InvoicePeriod,
+			row.StartDate,
+			row.EndDate,
+			strconv.FormatFloat(row.ActualCost, 'f', -1, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the rows as an indented JSON array
+func WriteJSON(w io.Writer, rows []Row) error {
+	if rows == nil {
+		rows = []Row{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// parseDateFlag parses the value of a date flag, empty values give the zero time
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, ok := cloudportal.ParseBillingDate(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid -%s %q, expected a date such as 2024-01 or 2024-01-15", name, value)
+	}
+	return date, nil
+}
+
+// setFlagsFromEnv sets the flags that were not given on the command line from
+// their environment variables, if set
+func setFlagsFromEnv(flags *flag.FlagSet, names map[string]string) error {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for name, env := range names {
+		value := os.Getenv(env)
+		if value == "" || given[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s %q: %s", env, value, err)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, ignoring empty entries
+func splitList(s string) []string {
+	var result []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// envDefault returns the value of the first set environment variable, the
+// provider reads its settings from the same variables
+func envDefault(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
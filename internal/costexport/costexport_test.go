@@ -0,0 +1,173 @@
+package costexport
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// testSummary is a fixed billing summary with two billing items
+var testSummary = &cloudportal.BillingSummary{
+	Items: []cloudportal.TicketBillingItem{
+		{
+			TicketID:    "a",
+			TicketNo:    1,
+			ClarityCode: "C1",
+			BillingItem: cloudportal.BillingItem{SubscriptionName: "s1"},
+			InvoicePeriods: []cloudportal.InvoicePeriod{
+				{InvoicePeriod: "Dec 2023", StartDate: "12/1/2023", EndDate: "12/31/2023", ActualCost: 4},
+				{InvoicePeriod: "Jan 2024", StartDate: "1/1/2024", EndDate: "1/31/2024", ActualCost: 1.5},
+				{InvoicePeriod: "Feb 2024", StartDate: "2/1/2024", EndDate: "2/29/2024", ActualCost: 2},
+			},
+		},
+		{
+			TicketID:    "b",
+			TicketNo:    2,
+			ClarityCode: "C2",
+			BillingItem: cloudportal.BillingItem{SubscriptionName: "s2"},
+			InvoicePeriods: []cloudportal.InvoicePeriod{
+				{InvoicePeriod: "2024-01", ActualCost: 10},
+				{InvoicePeriod: "adjustment", ActualCost: -1},
+			},
+		},
+	},
+}
+
+func date(value string) time.Time {
+	d, _ := cloudportal.ParseBillingDate(value)
+	return d
+}
+
+func TestRows(t *testing.T) {
+	cases := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"all periods", time.Time{}, time.Time{}, []string{"a/Dec 2023", "a/Jan 2024", "a/Feb 2024", "b/2024-01", "b/adjustment"}},
+		{"from", date("2024-01"), time.Time{}, []string{"a/Jan 2024", "a/Feb 2024", "b/2024-01"}},
+		{"to", time.Time{}, date("2024-01"), []string{"a/Dec 2023", "a/Jan 2024", "b/2024-01"}},
+		{"from and to", date("2024-01"), date("2024-01-31"), []string{"a/Jan 2024", "b/2024-01"}},
+		{"empty range", date("2025-01"), time.Time{}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, row := range Rows(testSummary, tc.from, tc.to) {
+				got = append(got, row.TicketID+"/"+row.InvoicePeriod)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rows = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteRows(t *testing.T) {
+	rows := Rows(testSummary, date("2024-01"), date("2024-01"))
+
+	cases := []struct {
+		name  string
+		write func(io.Writer, []Row) error
+		rows  []Row
+		want  string
+	}{
+		{
+			name:  "csv",
+			write: WriteCSV,
+			rows:  rows,
+			want: "ticketid,ticketno,claritycode,subscriptionname,invoiceperiod,startdate,enddate,actualcost\n" +
+				"a,1,C1,s1,Jan 2024,1/1/2024,1/31/2024,1.5\n" +
+				"b,2,C2,s2,2024-01,,,10\n",
+		},
+		{
+			name:  "csv without rows",
+			write: WriteCSV,
+			want:  "ticketid,ticketno,claritycode,subscriptionname,invoiceperiod,startdate,enddate,actualcost\n",
+		},
+		{
+			name:  "json",
+			write: WriteJSON,
+			rows:  rows[:1],
+			want: `[
+  {
+    "ticketid": "a",
+    "ticketno": 1,
+    "claritycode": "C1",
+    "subscriptionname": "s1",
+    "invoiceperiod": "Jan 2024",
+    "startdate": "1/1/2024",
+    "enddate": "1/31/2024",
+    "actualcost": 1.5
+  }
+]
+`,
+		},
+		{
+			name:  "json without rows",
+			write: WriteJSON,
+			want:  "[]\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.write(&buf, tc.rows); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	t.Setenv("CLOUDPORTAL_MAX_RETRIES", "7")
+	t.Setenv("CLOUDPORTAL_RETRY_MIN_WAIT", "5s")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	maxRetries := flags.Int("max-retries", 3, "")
+	minWait := flags.Duration("retry-min-wait", time.Second, "")
+	maxWait := flags.Duration("retry-max-wait", 30*time.Second, "")
+	if err := flags.Parse([]string{"-retry-min-wait", "2s"}); err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]string{
+		"max-retries":    "CLOUDPORTAL_MAX_RETRIES",
+		"retry-min-wait": "CLOUDPORTAL_RETRY_MIN_WAIT",
+		"retry-max-wait": "CLOUDPORTAL_RETRY_MAX_WAIT",
+	}
+	if err := setFlagsFromEnv(flags, names); err != nil {
+		t.Fatal(err)
+	}
+
+	// The command line wins over the environment
+	if *maxRetries != 7 || *minWait != 2*time.Second || *maxWait != 30*time.Second {
+		t.Errorf("flags = %d %s %s, want 7 2s 30s", *maxRetries, *minWait, *maxWait)
+	}
+
+	t.Setenv("CLOUDPORTAL_MAX_RETRIES", "many")
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("max-retries", 3, "")
+	if err := setFlagsFromEnv(flags, map[string]string{"max-retries": names["max-retries"]}); err == nil || !strings.Contains(err.Error(), "CLOUDPORTAL_MAX_RETRIES") {
+		t.Errorf("err = %v, want it to name CLOUDPORTAL_MAX_RETRIES", err)
+	}
+}
+
+func TestRunRequiresTokenScope(t *testing.T) {
+	for _, name := range []string{"CLOUDPORTAL_TENANT_ID", "AZURE_TENANT_ID", "CLOUDPORTAL_APPLICATION_ID_URI"} {
+		t.Setenv(name, "")
+	}
+
+	err := Run([]string{"-base-url", "https://portal.example.com", "-ticket-ids", "t1"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "-tenant-id") || !strings.Contains(err.Error(), "-application-id-uri") {
+		t.Fatalf("err = %v, want it to name -tenant-id and -application-id-uri", err)
+	}
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// credentialConfigFromResourceData reads the authentication settings of the provider
+func credentialConfigFromResourceData(d *schema.ResourceData) cloudportal.CredentialConfig {
+	return cloudportal.CredentialConfig{
+		AuthMethod:                d.Get("auth_method").(string),
+		TenantID:                  d.Get("tenantID").(string),
+		ClientID:                  d.Get("clientID").(string),
+		ClientSecret:              d.Get("clientSecret").(string),
+		ClientCertificatePath:     d.Get("client_certificate_path").(string),
+		ClientCertificatePassword: d.Get("client_certificate_password").(string),
+		OIDCTokenFilePath:         d.Get("oidc_token_file_path").(string),
+		AuthorityHost:             d.Get("authority_host").(string),
+	}
+}
+
+// transportConfigFromResourceData reads the proxy and TLS settings of the provider
+func transportConfigFromResourceData(d *schema.ResourceData) cloudportal.TransportConfig {
+	return cloudportal.TransportConfig{
+		ProxyURL:       d.Get("proxy_url").(string),
+		NoProxy:        d.Get("no_proxy").(string),
+		CACertFile:     d.Get("ca_cert_file").(string),
+		CACertPEM:      d.Get("ca_cert_pem").(string),
+		ClientCertFile: d.Get("tls_client_cert_file").(string),
+		ClientKeyFile:  d.Get("tls_client_key_file").(string),
+		MinTLSVersion:  d.Get("min_tls_version").(string),
+	}
+}
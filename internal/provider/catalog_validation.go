@@ -0,0 +1,284 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// resourceTicketCatalogItemsDiff validates the configured catalog items of a
+// ticket against their catalog definitions, so mistakes surface during plan
+// instead of as API errors during apply. Findings are returned as cty.PathError
+// so Terraform attaches them to the offending attribute.
+func resourceTicketCatalogItemsDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange("catalogitems") || !d.NewValueKnown("catalogitems") {
+		return nil
+	}
+
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	raw := d.GetRawConfig()
+
+	var errs []error
+	for i, v := range d.Get("catalogitems").([]interface{}) {
+		m := v.(map[string]interface{})
+		path := cty.GetAttrPath("catalogitems").IndexInt(i)
+
+		// Items that still depend on other resources are validated once known
+		unknown, known := unknownCatalogFieldKeys(raw, i)
+		if !known {
+			continue
+		}
+
+		name := m["name"].(string)
+		if name == "" {
+			continue
+		}
+
+		definition, err := client.GetCatalogItem(ctx, name, m["catalogitemversion"].(int))
+		if err != nil {
+			if cloudportal.IsNotFound(err) {
+				errs = append(errs, path.GetAttr("name").NewErrorf("catalog item %q does not exist", name))
+				continue
+			}
+			return fmt.Errorf("error reading catalog item %s: %s", name, err)
+		}
+
+		errs = append(errs, validateCatalogFields(path, definition, m["catalogfields"].([]interface{}), unknown)...)
+	}
+
+	return joinPathErrors(errs)
+}
+
+// unknownCatalogFieldKeys returns the keys of the catalog fields of the i-th
+// configured catalog item whose value is not known yet. It reports false when
+// the name of the item, its list of fields or one of the keys is unknown.
+func unknownCatalogFieldKeys(raw cty.Value, i int) (map[string]bool, bool) {
+	unknown := make(map[string]bool)
+	if raw.IsNull() || !raw.IsKnown() {
+		return unknown, true
+	}
+
+	items := raw.GetAttr("catalogitems")
+	if !items.IsKnown() {
+		return nil, false
+	}
+	if items.IsNull() || i >= items.LengthInt() {
+		return unknown, true
+	}
+
+	item := items.Index(cty.NumberIntVal(int64(i)))
+	if !item.IsKnown() || !item.GetAttr("name").IsKnown() {
+		return nil, false
+	}
+
+	fields := item.GetAttr("catalogfields")
+	if !fields.IsKnown() {
+		return nil, false
+	}
+	if fields.IsNull() {
+		return unknown, true
+	}
+
+	for it := fields.ElementIterator(); it.Next(); {
+		_, field := it.Element()
+		if !field.IsKnown() {
+			return nil, false
+		}
+		key, value := field.GetAttr("key"), field.GetAttr("value")
+		if !key.IsKnown() {
+			return nil, false
+		}
+		if !value.IsKnown() && !key.IsNull() {
+			unknown[key.AsString()] = true
+		}
+	}
+
+	return unknown, true
+}
+
+// validateCatalogFields checks the configured catalog fields against the
+// field definitions of the catalog item. Values of the fields listed in
+// unknown are not known until apply and only count as supplied.
+func validateCatalogFields(path cty.Path, definition *cloudportal.CatalogItem, configured []interface{}, unknown map[string]bool) []error {
+	var errs []error
+
+	values := make(map[string]string)
+	indexes := make(map[string]int)
+	for i, v := range configured {
+		m := v.(map[string]interface{})
+		key := m["key"].(string)
+		values[key] = m["value"].(string)
+		indexes[key] = i
+	}
+
+	definitions := make(map[string]cloudportal.CatalogField)
+	for _, field := range definition.CatalogFields {
+		definitions[field.Key] = field
+	}
+
+	fieldsPath := path.GetAttr("catalogfields")
+	for i, v := range configured {
+		key := v.(map[string]interface{})["key"].(string)
+		if _, ok := definitions[key]; !ok {
+			errs = append(errs, fieldsPath.IndexInt(i).GetAttr("key").NewErrorf("catalog item %q has no field %q", definition.Name, key))
+		}
+	}
+
+	for _, field := range definition.CatalogFields {
+		value, supplied := values[field.Key]
+		valuePath := fieldsPath.IndexInt(indexes[field.Key]).GetAttr("value")
+
+		// The field that toggles this one is not known yet
+		if toggle := stringValue(field.EnableToggleBy); toggle != "" && unknown[toggle] {
+			continue
+		}
+		enabled := catalogFieldEnabled(field, values)
+
+		if unknown[field.Key] {
+			if !enabled {
+				errs = append(errs, valuePath.NewErrorf("field %q (%s) is disabled and cannot be set", field.Key, field.Label))
+			}
+			continue
+		}
+
+		if !enabled {
+			if supplied && value != "" {
+				errs = append(errs, valuePath.NewErrorf("field %q (%s) is disabled and cannot be set", field.Key, field.Label))
+			}
+			continue
+		}
+
+		if !supplied || value == "" {
+			if field.IsMandatory && field.Value == "" {
+				errs = append(errs, fieldsPath.NewErrorf("field %q (%s) is mandatory for catalog item %q", field.Key, field.Label, definition.Name))
+			}
+			continue
+		}
+
+		if err := validateCatalogFieldValue(field, value); err != nil {
+			errs = append(errs, valuePath.NewError(err))
+		}
+	}
+
+	return errs
+}
+
+// joinPathErrors combines the findings into a single error. Terraform only
+// takes the attribute path of a cty.PathError into account, so the first
+// finding keeps its path and the others are listed with their path in the
+// message.
+func joinPathErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	first, ok := errs[0].(cty.PathError)
+	if !ok {
+		return errors.Join(errs...)
+	}
+
+	messages := []error{errors.New(first.Error())}
+	for _, err := range errs[1:] {
+		if pathErr, ok := err.(cty.PathError); ok {
+			err = fmt.Errorf("%s: %s", formatPath(pathErr.Path), pathErr.Error())
+		}
+		messages = append(messages, err)
+	}
+	return first.Path.NewError(errors.Join(messages...))
+}
+
+// formatPath renders an attribute path in the flatmap notation used by
+// ResourceData, e.g. catalogitems.0.catalogfields.1.value
+func formatPath(path cty.Path) string {
+	var parts []string
+	for _, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			parts = append(parts, s.Name)
+		case cty.IndexStep:
+			if s.Key.Type() == cty.Number {
+				i, _ := s.Key.AsBigFloat().Int64()
+				parts = append(parts, strconv.FormatInt(i, 10))
+			} else {
+				parts = append(parts, s.Key.AsString())
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// catalogFieldEnabled reports whether the field can be set, taking its
+// disabled flag and the field that toggles it into account
+func catalogFieldEnabled(field cloudportal.CatalogField, values map[string]string) bool {
+	if isTrue(stringValue(field.Disabled)) {
+		return false
+	}
+	if toggle := stringValue(field.EnableToggleBy); toggle != "" {
+		return isTrue(values[toggle])
+	}
+	return true
+}
+
+// validateCatalogFieldValue checks a single value against the lookup values,
+// input type and input format of the field
+func validateCatalogFieldValue(field cloudportal.CatalogField, value string) error {
+	if len(field.LookupValues) > 0 {
+		allowed := false
+		for _, lookup := range field.LookupValues {
+			if lookup == value {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("value %q of field %q is not one of: %s", value, field.Key, strings.Join(field.LookupValues, ", "))
+		}
+	}
+
+	var err error
+	switch strings.ToLower(stringValue(field.InputType)) {
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "int", "integer":
+		_, err = strconv.Atoi(value)
+	case "bool", "boolean", "checkbox", "toggle":
+		_, err = strconv.ParseBool(value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return fmt.Errorf("value %q of field %q is not a valid %s", value, field.Key, stringValue(field.InputType))
+	}
+
+	if format := stringValue(field.InputFormat); format != "" {
+		// Formats the portal uses for display only are not regular expressions
+		if re, err := regexp.Compile("^(?:" + format + ")$"); err == nil && !re.MatchString(value) {
+			return fmt.Errorf("value %q of field %q does not match the format %s", value, field.Key, format)
+		}
+	}
+
+	return nil
+}
+
+// isTrue interprets the string flags used by the catalog
+func isTrue(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "1", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+func TestValidateCatalogFields(t *testing.T) {
+	p := func(s string) *string { return &s }
+	definition := &cloudportal.CatalogItem{Name: "vm", CatalogFields: []cloudportal.CatalogField{
+		{Key: "size", Label: "Size", IsMandatory: true, LookupValues: []string{"S", "M"}},
+		{Key: "count", InputType: p("number")},
+		{Key: "name", InputFormat: p("[a-z]+")},
+		{Key: "backup", InputType: p("checkbox")},
+		{Key: "retention", EnableToggleBy: p("backup"), IsMandatory: true},
+		{Key: "legacy", Disabled: p("true")},
+		{Key: "subnet", IsMandatory: true},
+	}}
+	fields := func(kv ...string) []interface{} {
+		var result []interface{}
+		for i := 0; i < len(kv); i += 2 {
+			result = append(result, map[string]interface{}{"key": kv[i], "value": kv[i+1]})
+		}
+		return result
+	}
+
+	cases := []struct {
+		name       string
+		configured []interface{}
+		unknown    map[string]bool
+		want       []string
+	}{
+		{
+			name:       "valid",
+			configured: fields("size", "S", "count", "3", "name", "abc", "subnet", "s1"),
+		},
+		{
+			name:       "mandatory field missing",
+			configured: fields("size", "S"),
+			want:       []string{"catalogitems.0.catalogfields"},
+		},
+		{
+			name:       "mandatory field not known until apply",
+			configured: fields("size", "S", "subnet", ""),
+			unknown:    map[string]bool{"subnet": true},
+		},
+		{
+			name:       "toggled field required once enabled",
+			configured: fields("size", "S", "subnet", "s1", "backup", "true"),
+			want:       []string{"catalogitems.0.catalogfields"},
+		},
+		{
+			name:       "toggle not known until apply",
+			configured: fields("size", "S", "subnet", "s1", "backup", ""),
+			unknown:    map[string]bool{"backup": true},
+		},
+		{
+			name:       "invalid values",
+			configured: fields("size", "XL", "count", "x", "name", "AB", "legacy", "y", "bogus", "1", "subnet", "s1"),
+			want: []string{
+				"catalogitems.0.catalogfields.0.value",
+				"catalogitems.0.catalogfields.1.value",
+				"catalogitems.0.catalogfields.2.value",
+				"catalogitems.0.catalogfields.3.value",
+				"catalogitems.0.catalogfields.4.key",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateCatalogFields(cty.GetAttrPath("catalogitems").IndexInt(0), definition, tc.configured, tc.unknown)
+
+			var paths []string
+			for _, err := range errs {
+				pathErr, ok := err.(cty.PathError)
+				if !ok {
+					t.Fatalf("%s is not a cty.PathError", err)
+				}
+				paths = append(paths, formatPath(pathErr.Path))
+			}
+			sort.Strings(paths)
+			if !reflect.DeepEqual(paths, tc.want) {
+				t.Errorf("paths = %v, want %v (%v)", paths, tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestUnknownCatalogFieldKeys(t *testing.T) {
+	field := func(key, value cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{"key": key, "value": value})
+	}
+	config := func(name cty.Value, fields ...cty.Value) cty.Value {
+		list := cty.ListValEmpty(cty.Object(map[string]cty.Type{"key": cty.String, "value": cty.String}))
+		if len(fields) > 0 {
+			list = cty.ListVal(fields)
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			"catalogitems": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"name":          name,
+				"catalogfields": list,
+			})}),
+		})
+	}
+
+	cases := []struct {
+		name      string
+		raw       cty.Value
+		wantKnown bool
+		want      map[string]bool
+	}{
+		{
+			name:      "all known",
+			raw:       config(cty.StringVal("vm"), field(cty.StringVal("size"), cty.StringVal("S"))),
+			wantKnown: true,
+			want:      map[string]bool{},
+		},
+		{
+			name:      "unknown value",
+			raw:       config(cty.StringVal("vm"), field(cty.StringVal("subnet"), cty.UnknownVal(cty.String))),
+			wantKnown: true,
+			want:      map[string]bool{"subnet": true},
+		},
+		{
+			name: "unknown key",
+			raw:  config(cty.StringVal("vm"), field(cty.UnknownVal(cty.String), cty.StringVal("x"))),
+		},
+		{
+			name: "unknown name",
+			raw:  config(cty.UnknownVal(cty.String)),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			unknown, known := unknownCatalogFieldKeys(tc.raw, 0)
+			if known != tc.wantKnown {
+				t.Fatalf("known = %t, want %t", known, tc.wantKnown)
+			}
+			if known && !reflect.DeepEqual(unknown, tc.want) {
+				t.Errorf("unknown = %v, want %v", unknown, tc.want)
+			}
+		})
+	}
+}
+
+func TestJoinPathErrors(t *testing.T) {
+	path := cty.GetAttrPath("catalogitems").IndexInt(0).GetAttr("catalogfields")
+	err := joinPathErrors([]error{
+		path.IndexInt(0).GetAttr("value").NewErrorf("first"),
+		path.IndexInt(2).GetAttr("key").NewErrorf("second"),
+	})
+
+	pathErr, ok := err.(cty.PathError)
+	if !ok {
+		t.Fatalf("%s is not a cty.PathError", err)
+	}
+	if got := formatPath(pathErr.Path); got != "catalogitems.0.catalogfields.0.value" {
+		t.Errorf("path = %s", got)
+	}
+	if !strings.Contains(err.Error(), "first") || !strings.Contains(err.Error(), "catalogitems.0.catalogfields.2.key: second") {
+		t.Errorf("message = %q", err)
+	}
+	if joinPathErrors(nil) != nil {
+		t.Error("expected nil for no findings")
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceBilling defines the cloudportal_billing data source which
+// aggregates the billing items of a set of tickets
+func dataSourceBilling() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceBillingRead,
+		Schema: map[string]*schema.Schema{
+			"ticketids": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: []string{"ticketids", "claritycodes", "subscriptionnames"},
+				Description:  "Identifiers of the tickets to aggregate",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+			},
+			"claritycodes": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: []string{"ticketids", "claritycodes", "subscriptionnames"},
+				Description:  "Aggregate all tickets booked on these clarity codes",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+			},
+			"subscriptionnames": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: []string{"ticketids", "claritycodes", "subscriptionnames"},
+				Description:  "Only include billing items of these subscriptions. Without ticketids and claritycodes all tickets billed to these subscriptions are aggregated",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+			},
+			"billingitems": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Billing items of the tickets",
+				Elem:        ticketbillingitemschema(),
+			},
+			"subscriptiontotals": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Total cost per subscription name",
+				Elem:        &schema.Schema{Type: schema.TypeFloat},
+			},
+			"claritycodetotals": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Total cost per clarity code",
+				Elem:        &schema.Schema{Type: schema.TypeFloat},
+			},
+			"periodtotals": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Total cost per invoice period",
+				Elem:        &schema.Schema{Type: schema.TypeFloat},
+			},
+			"totalcost": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Total cost of all billing items",
+			},
+		},
+	}
+}
+
+// dataSourceBillingRead fetches the tickets and aggregates their billing items
+func dataSourceBillingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketIDs := expandStringList(d.Get("ticketids").([]interface{}))
+	clarityCodes := expandStringList(d.Get("claritycodes").([]interface{}))
+	subscriptions := expandStringList(d.Get("subscriptionnames").([]interface{}))
+
+	// Subscriptions only select the tickets when no tickets are named otherwise,
+	// else they narrow down the billing items of those tickets
+	var subscriptionTickets []string
+	if len(ticketIDs) == 0 && len(clarityCodes) == 0 {
+		subscriptionTickets = subscriptions
+	}
+
+	tickets, err := client.GetBillingTickets(ctx, ticketIDs, clarityCodes, subscriptionTickets)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	summary := cloudportal.SummarizeBilling(tickets, subscriptions)
+
+	d.Set("billingitems", flattenTicketBillingItems(summary.Items))
+	d.Set("subscriptiontotals", summary.SubscriptionTotals)
+	d.Set("claritycodetotals", summary.ClarityCodeTotals)
+	d.Set("periodtotals", summary.PeriodTotals)
+	d.Set("totalcost", summary.TotalCost)
+
+	sort.Strings(ticketIDs)
+	sort.Strings(clarityCodes)
+	sort.Strings(subscriptions)
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ticketIDs, ",") + "/" + strings.Join(clarityCodes, ",") + "/" + strings.Join(subscriptions, ","))))
+
+	return nil
+}
+
+// Helper function to flatten billing items together with their ticket
+func flattenTicketBillingItems(items []cloudportal.TicketBillingItem) []interface{} {
+	var result []interface{}
+	for _, item := range items {
+		result = append(result, map[string]interface{}{
+			"ticketid":         item.TicketID,
+			"ticketno":         item.TicketNo,
+			"claritycode":      item.ClarityCode,
+			"id":               item.BillingItem.ID,
+			"partitionkey":     item.BillingItem.PartitionKey,
+			"subscriptionname": item.BillingItem.SubscriptionName,
+			"invoiceperiods":   flattenInvoicePeriods(item.InvoicePeriods),
+			"totalcost":        item.TotalCost,
+		})
+	}
+	return result
+}
+
+// Helper function to expand a list of strings from configuration
+func expandStringList(list []interface{}) []string {
+	result := make([]string, 0, len(list))
+	for _, v := range list {
+		result = append(result, v.(string))
+	}
+	return result
+}
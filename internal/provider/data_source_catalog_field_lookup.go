@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceCatalogFieldLookup defines the cloudportal_catalog_field_lookup
+// data source which resolves the allowed values of a catalog field through its
+// server-side lookup function
+func dataSourceCatalogFieldLookup() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCatalogFieldLookupRead,
+		Schema: map[string]*schema.Schema{
+			"lookupfunction": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"lookupfunction", "catalogitem"},
+				Description:  "Name of the lookup function to invoke",
+			},
+			"catalogitem": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"catalogitem", "key"},
+				Description:  "Name of the catalog item whose field lookup function is invoked",
+			},
+			"catalogitemversion": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Version of the catalog item, defaults to the latest version",
+			},
+			"key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"catalogitem", "key"},
+				Description:  "Key of the catalog field whose lookup function is invoked",
+			},
+			"parameters": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Values of the fields the lookup depends on, keyed by field key",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"values": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Allowed values returned by the lookup",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"value": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Value to use in the catalog field",
+						},
+						"label": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Display label of the value",
+						},
+					},
+				},
+			},
+			"allowedvalues": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Allowed values returned by the lookup, without labels",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// dataSourceCatalogFieldLookupRead invokes the lookup function
+func dataSourceCatalogFieldLookupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	function := d.Get("lookupfunction").(string)
+	if name, ok := d.GetOk("catalogitem"); ok {
+		item, err := client.GetCatalogItem(ctx, name.(string), d.Get("catalogitemversion").(int))
+		if err != nil {
+			return diag.Errorf("error reading catalog item %s: %s", name, err)
+		}
+
+		key := d.Get("key").(string)
+		function = ""
+		for _, field := range item.CatalogFields {
+			if field.Key == key {
+				function = stringValue(field.LookupFunction)
+			}
+		}
+		if function == "" {
+			return diag.Errorf("field %q of catalog item %s has no lookup function", key, name)
+		}
+	}
+
+	parameters := expandStringMap(d.Get("parameters").(map[string]interface{}))
+
+	values, err := client.LookupCatalogField(ctx, function, parameters)
+	if err != nil {
+		return diag.Errorf("error invoking lookup function %s: %s", function, err)
+	}
+
+	var result, allowed []interface{}
+	for _, v := range values {
+		result = append(result, map[string]interface{}{
+			"value": v.Value,
+			"label": v.Label,
+		})
+		allowed = append(allowed, v.Value)
+	}
+
+	d.Set("lookupfunction", function)
+	d.Set("values", result)
+	d.Set("allowedvalues", allowed)
+
+	// The id covers the function and its inputs so different lookups do not collide
+	keys := make([]string, 0, len(parameters))
+	for k := range parameters {
+		keys = append(keys, k+"="+parameters[k])
+	}
+	sort.Strings(keys)
+	d.SetId(strconv.Itoa(schema.HashString(function + "?" + strings.Join(keys, "&"))))
+
+	return nil
+}
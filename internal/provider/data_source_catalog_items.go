@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceCatalogItems defines the cloudportal_catalog_items data source
+// which lists the items of the service catalog
+func dataSourceCatalogItems() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCatalogItemsRead,
+		Schema: map[string]*schema.Schema{
+			"catalogitemcloudplatform": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return catalog items for this cloud platform (e.g., Azure)",
+			},
+			"tickettypes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Only return catalog items available for any of these ticket types",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"active": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only return active (true) or inactive (false) catalog items",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Names of the matching catalog items",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"catalogitems": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching catalog items",
+				Elem:        catalogitemschema(),
+			},
+		},
+	}
+}
+
+// dataSourceCatalogItemsRead lists the catalog items matching the configured filters
+func dataSourceCatalogItemsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	items, err := client.ListCatalogItems(ctx)
+	if err != nil {
+		return diag.Errorf("error listing catalog items: %s", err)
+	}
+
+	platform := d.Get("catalogitemcloudplatform").(string)
+	var ticketTypes []string
+	for _, v := range d.Get("tickettypes").([]interface{}) {
+		ticketTypes = append(ticketTypes, v.(string))
+	}
+	// A bool left out of the configuration reads as false, so look at the raw
+	// configuration to tell whether the active filter was set
+	filterActive := !d.GetRawConfig().GetAttr("active").IsNull()
+	active := d.Get("active").(bool)
+
+	var matched []cloudportal.CatalogItem
+	var names []interface{}
+	for _, item := range items {
+		if platform != "" && !strings.EqualFold(item.CatalogItemCloudPlatform, platform) {
+			continue
+		}
+		if len(ticketTypes) > 0 && !containsAnyFold(item.TicketTypes, ticketTypes) {
+			continue
+		}
+		if filterActive && item.Active != active {
+			continue
+		}
+		matched = append(matched, item)
+		names = append(names, item.Name)
+	}
+
+	d.Set("names", names)
+	d.Set("catalogitems", flattenCatalogItems(matched))
+
+	d.SetId(strconv.Itoa(schema.HashString(fmt.Sprintf("%s/%v/%v/%t", platform, ticketTypes, filterActive, active))))
+
+	return nil
+}
+
+// dataSourceCatalogItem defines the cloudportal_catalog_item data source which
+// fetches a single catalog item by name and optional version
+func dataSourceCatalogItem() *schema.Resource {
+	s := computedExcept(catalogitemschema(), "name", "catalogitemversion").Schema
+	s["catalogitemversion"].Required = false
+	s["catalogitemversion"].Optional = true
+	s["catalogitemversion"].Computed = true
+	s["catalogitemversion"].Description = "Version of the catalog item, defaults to the latest version"
+	s["mandatoryfields"] = &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Keys of the catalog fields that must be supplied when ordering the item",
+		Elem:        &schema.Schema{Type: schema.TypeString},
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceCatalogItemRead,
+		Schema:      s,
+	}
+}
+
+// dataSourceCatalogItemRead reads a single catalog item from the API
+func dataSourceCatalogItemRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	name := d.Get("name").(string)
+	item, err := client.GetCatalogItem(ctx, name, d.Get("catalogitemversion").(int))
+	if err != nil {
+		return diag.Errorf("error reading catalog item %s: %s", name, err)
+	}
+
+	for k, v := range flattenCatalogItems([]cloudportal.CatalogItem{*item})[0].(map[string]interface{}) {
+		d.Set(k, v)
+	}
+
+	var mandatory []interface{}
+	for _, field := range item.CatalogFields {
+		if field.IsMandatory {
+			mandatory = append(mandatory, field.Key)
+		}
+	}
+	d.Set("mandatoryfields", mandatory)
+
+	d.SetId(fmt.Sprintf("%s/%d", item.Name, item.CatalogItemVersion))
+
+	return nil
+}
+
+// containsAnyFold reports whether any of the wanted values is in list, ignoring case
+func containsAnyFold(list []string, wanted []string) bool {
+	for _, item := range list {
+		for _, w := range wanted {
+			if strings.EqualFold(item, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
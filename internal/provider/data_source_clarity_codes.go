@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceClarityCode defines the cloudportal_clarity_code data source which
+// looks up a single clarity code, failing when the code does not exist
+func dataSourceClarityCode() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceClarityCodeRead,
+		Schema:      computedExcept(claritycodeschema(), "code").Schema,
+	}
+}
+
+// dataSourceClarityCodeRead reads the clarity code from the API
+func dataSourceClarityCodeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	code := d.Get("code").(string)
+
+	clarityCode, err := client.GetClarityCode(ctx, code)
+	if err != nil {
+		if cloudportal.IsNotFound(err) {
+			return diag.Errorf("clarity code %q does not exist", code)
+		}
+		return diag.Errorf("error reading clarity code %s: %s", code, err)
+	}
+
+	d.Set("description", clarityCode.Description)
+	d.Set("costcenter", clarityCode.CostCenter)
+	d.Set("emails", flattenStringList(clarityCode.Emails))
+	d.Set("tower", clarityCode.Tower)
+
+	d.SetId(clarityCode.Code)
+
+	return nil
+}
+
+// dataSourceClarityCodes defines the cloudportal_clarity_codes data source
+// which lists clarity codes, optionally filtered by tower and cost center
+func dataSourceClarityCodes() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceClarityCodesRead,
+		Schema: map[string]*schema.Schema{
+			"tower": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return clarity codes of this tower",
+			},
+			"costcenter": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return clarity codes of this cost center",
+			},
+			"codes": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching clarity codes",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"claritycodes": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Details of the matching clarity codes",
+				Elem:        claritycodeschema(),
+			},
+		},
+	}
+}
+
+// dataSourceClarityCodesRead lists the clarity codes matching the filters
+func dataSourceClarityCodesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	clarityCodes, err := client.ListClarityCodes(ctx)
+	if err != nil {
+		return diag.Errorf("error listing clarity codes: %s", err)
+	}
+
+	tower := d.Get("tower").(string)
+	costCenter := d.Get("costcenter").(string)
+
+	var codes, result []interface{}
+	for _, clarityCode := range clarityCodes {
+		if tower != "" && !strings.EqualFold(clarityCode.Tower, tower) {
+			continue
+		}
+		if costCenter != "" && !strings.EqualFold(clarityCode.CostCenter, costCenter) {
+			continue
+		}
+		codes = append(codes, clarityCode.Code)
+		result = append(result, flattenClarityCode(clarityCode)...)
+	}
+
+	d.Set("codes", codes)
+	d.Set("claritycodes", result)
+
+	d.SetId(strconv.Itoa(schema.HashString(tower + "/" + costCenter)))
+
+	return nil
+}
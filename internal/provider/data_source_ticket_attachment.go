@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceTicketAttachment defines the cloudportal_ticket_attachment data
+// source which downloads the content of an attachment by filename
+func dataSourceTicketAttachment() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceTicketAttachmentRead,
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Unique identifier of the ticket the file is attached to",
+			},
+			"filename": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the attachment file",
+			},
+			"outputpath": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Local path the attachment is written to",
+			},
+			"content": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Content of the attachment as a UTF-8 string",
+			},
+			"contentbase64": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Base64 encoded content of the attachment, for binary files",
+			},
+			"contentsha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 hash of the attachment content",
+			},
+			"url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "URL of the attachment",
+			},
+			"uploaddatetime": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Upload timestamp of the attachment",
+			},
+			"uploadedby": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     userschema(),
+			},
+		},
+	}
+}
+
+// dataSourceTicketAttachmentRead downloads the attachment
+func dataSourceTicketAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+	filename := d.Get("filename").(string)
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	attachment := findAttachment(ticket.Attachments, filename)
+	if attachment == nil {
+		return diag.Errorf("ticket %s has no attachment %q", ticketID, filename)
+	}
+
+	content, err := client.DownloadAttachment(ctx, ticketID, filename)
+	if err != nil {
+		return diag.Errorf("error downloading %s from ticket %s: %s", filename, ticketID, err)
+	}
+
+	if path, ok := d.GetOk("outputpath"); ok {
+		if err := os.WriteFile(path.(string), content, 0644); err != nil {
+			return diag.Errorf("error writing %s: %s", path, err)
+		}
+	}
+
+	d.Set("content", string(content))
+	d.Set("contentbase64", base64.StdEncoding.EncodeToString(content))
+	d.Set("contentsha256", contentSHA256(content))
+	d.Set("url", attachment.URL)
+	d.Set("uploaddatetime", attachment.UploadDateTime)
+	d.Set("uploadedby", flattenUsers(attachment.UploadedBy))
+
+	d.SetId(ticketID + "/" + filename)
+
+	return nil
+}
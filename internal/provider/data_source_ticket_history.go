@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// dataSourceTicketHistory defines the cloudportal_ticket_history data source
+// which returns the change history of a ticket for audits
+func dataSourceTicketHistory() *schema.Resource {
+	changes := changeschema()
+	changes.Schema["diff"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Human readable diff of the old and new value",
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceTicketHistoryRead,
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Unique identifier of the ticket",
+			},
+			"propertynames": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Only return changes of these properties (e.g. status)",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"author": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return entries made by the user with this email, user principal name or display name",
+			},
+			"after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only return entries made at or after this RFC 3339 timestamp",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only return entries made before this RFC 3339 timestamp",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"historyitems": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching history entries, oldest first",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"date": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Date of history item",
+						},
+						"author": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     userschema(),
+						},
+						"changes": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "List of changes made to the ticket",
+							Elem:        changes,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// dataSourceTicketHistoryRead reads the ticket and filters its history
+func dataSourceTicketHistoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	filter := historyFilter{
+		propertyNames: expandStringList(d.Get("propertynames").([]interface{})),
+		author:        d.Get("author").(string),
+	}
+	if v, ok := d.GetOk("after"); ok {
+		filter.after, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	if v, ok := d.GetOk("before"); ok {
+		filter.before, _ = time.Parse(time.RFC3339, v.(string))
+	}
+
+	var result []interface{}
+	for _, item := range filterHistory(ticket.HistoryItems, filter) {
+		var changes []interface{}
+		for _, change := range item.Changes {
+			changes = append(changes, map[string]interface{}{
+				"propertyname": change.PropertyName,
+				"oldvalue":     flattenStringMap(change.OldValue),
+				"newvalue":     flattenStringMap(change.NewValue),
+				"diff":         renderChangeDiff(change),
+			})
+		}
+		result = append(result, map[string]interface{}{
+			"date":    item.Date,
+			"author":  flattenUsers(item.Author),
+			"changes": changes,
+		})
+	}
+
+	d.Set("historyitems", result)
+	d.SetId(ticketID)
+
+	return nil
+}
+
+// historyFilter selects history entries, zero values match everything
+type historyFilter struct {
+	propertyNames []string
+	author        string
+	after         time.Time
+	before        time.Time
+}
+
+// filterHistory returns the matching history entries sorted by date, oldest
+// first. Changes of other properties are dropped from the entries.
+func filterHistory(items []cloudportal.HistoryItem, filter historyFilter) []cloudportal.HistoryItem {
+	var result []cloudportal.HistoryItem
+	for _, item := range items {
+		if filter.author != "" && !historyAuthorMatches(item.Author, filter.author) {
+			continue
+		}
+
+		if !filter.after.IsZero() || !filter.before.IsZero() {
+			date, err := time.Parse(time.RFC3339, item.Date)
+			if err != nil {
+				continue
+			}
+			if !filter.after.IsZero() && date.Before(filter.after) {
+				continue
+			}
+			if !filter.before.IsZero() && !date.Before(filter.before) {
+				continue
+			}
+		}
+
+		if len(filter.propertyNames) > 0 {
+			var changes []cloudportal.Change
+			for _, change := range item.Changes {
+				if containsAnyFold(filter.propertyNames, []string{change.PropertyName}) {
+					changes = append(changes, change)
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			item.Changes = changes
+		}
+
+		result = append(result, item)
+	}
+
+	// Items with unparsable dates follow the dated ones, sorted by date string
+	sort.SliceStable(result, func(i, j int) bool {
+		a, errA := time.Parse(time.RFC3339, result[i].Date)
+		b, errB := time.Parse(time.RFC3339, result[j].Date)
+		if (errA == nil) != (errB == nil) {
+			return errA == nil
+		}
+		if errA != nil {
+			return result[i].Date < result[j].Date
+		}
+		return a.Before(b)
+	})
+
+	return result
+}
+
+// historyAuthorMatches reports whether any of the authors has the given
+// email, user principal name or display name
+func historyAuthorMatches(authors []cloudportal.User, author string) bool {
+	for _, user := range authors {
+		if strings.EqualFold(user.Email, author) ||
+			strings.EqualFold(user.UserPrincipalName, author) ||
+			strings.EqualFold(user.DisplayName, author) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderChangeDiff renders the old and new value of a change as one line per
+// changed key, e.g. ~ status.value: "Open" -> "Completed"
+func renderChangeDiff(change cloudportal.Change) string {
+	keys := make(map[string]bool)
+	for k := range change.OldValue {
+		keys[k] = true
+	}
+	for k := range change.NewValue {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		name := change.PropertyName + "." + k
+		oldValue, hadOld := change.OldValue[k]
+		newValue, hasNew := change.NewValue[k]
+
+		switch {
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("+ %s: %q", name, newValue))
+		case !hasNew:
+			lines = append(lines, fmt.Sprintf("- %s: %q", name, oldValue))
+		case oldValue != newValue:
+			lines = append(lines, fmt.Sprintf("~ %s: %q -> %q", name, oldValue, newValue))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
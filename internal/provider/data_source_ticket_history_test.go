@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+func TestFilterHistory(t *testing.T) {
+	items := []cloudportal.HistoryItem{
+		{Date: "2024-03-01T00:00:00Z", Author: []cloudportal.User{{Email: "a@example.com"}}, Changes: []cloudportal.Change{{PropertyName: "status"}, {PropertyName: "title"}}},
+		{Date: "2024-01-01T00:00:00Z", Author: []cloudportal.User{{Email: "b@example.com", DisplayName: "Bea"}}, Changes: []cloudportal.Change{{PropertyName: "title"}}},
+		{Date: "2024-02-01T00:00:00Z", Author: []cloudportal.User{{UserPrincipalName: "a@corp.example.com"}}, Changes: []cloudportal.Change{{PropertyName: "status"}}},
+		{Date: "not a date", Changes: []cloudportal.Change{{PropertyName: "status"}}},
+		{Date: "2024-02-15T10:00:00+01:00", Changes: []cloudportal.Change{{PropertyName: "title"}}},
+		{Date: "", Changes: []cloudportal.Change{{PropertyName: "title"}}},
+		{Date: "2023-12-31T23:00:00Z", Changes: []cloudportal.Change{{PropertyName: "title"}}},
+	}
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return d
+	}
+
+	cases := []struct {
+		name        string
+		filter      historyFilter
+		wantDates   []string
+		wantChanges int
+	}{
+		{
+			name:        "everything sorted oldest first, unparsable dates last",
+			wantDates:   []string{"2023-12-31T23:00:00Z", "2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z", "2024-02-15T10:00:00+01:00", "2024-03-01T00:00:00Z", "", "not a date"},
+			wantChanges: 8,
+		},
+		{
+			name:        "property names drop other changes",
+			filter:      historyFilter{propertyNames: []string{"Status"}},
+			wantDates:   []string{"2024-02-01T00:00:00Z", "2024-03-01T00:00:00Z", "not a date"},
+			wantChanges: 3,
+		},
+		{
+			name:        "author by email, upn or display name",
+			filter:      historyFilter{author: "BEA"},
+			wantDates:   []string{"2024-01-01T00:00:00Z"},
+			wantChanges: 1,
+		},
+		{
+			name:        "date range skips unparsable dates",
+			filter:      historyFilter{after: date("2024-02-01T00:00:00Z"), before: date("2024-03-01T00:00:00Z")},
+			wantDates:   []string{"2024-02-01T00:00:00Z", "2024-02-15T10:00:00+01:00"},
+			wantChanges: 2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := filterHistory(items, tc.filter)
+
+			var dates []string
+			changes := 0
+			for _, item := range result {
+				dates = append(dates, item.Date)
+				changes += len(item.Changes)
+			}
+			if !reflect.DeepEqual(dates, tc.wantDates) {
+				t.Errorf("dates = %v, want %v", dates, tc.wantDates)
+			}
+			if changes != tc.wantChanges {
+				t.Errorf("changes = %d, want %d", changes, tc.wantChanges)
+			}
+		})
+	}
+
+	if len(items[0].Changes) != 2 {
+		t.Error("filterHistory modified its input")
+	}
+}
+
+func TestRenderChangeDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		change cloudportal.Change
+		want   string
+	}{
+		{
+			name: "changed, added and removed keys",
+			change: cloudportal.Change{
+				PropertyName: "status",
+				OldValue:     map[string]string{"value": "Open", "reason": "new", "same": "x"},
+				NewValue:     map[string]string{"value": "Completed", "by": "approver", "same": "x"},
+			},
+			want: "+ status.by: \"approver\"\n- status.reason: \"new\"\n~ status.value: \"Open\" -> \"Completed\"",
+		},
+		{
+			name:   "no values",
+			change: cloudportal.Change{PropertyName: "title"},
+			want:   "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := renderChangeDiff(tc.change); got != tc.want {
+				t.Errorf("renderChangeDiff = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
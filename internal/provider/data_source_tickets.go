@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// ticketFilters maps the filter arguments of the cloudportal_tickets data
+// source to the query parameters of the ticket list endpoint
+var ticketFilters = map[string]string{
+	"status":          "status",
+	"type":            "type",
+	"cloudplatform":   "cloudplatform",
+	"serviceprovider": "serviceprovider",
+	"createdbyemail":  "createdby",
+	"claritycode":     "claritycode",
+	"createdafter":    "createdafter",
+	"createdbefore":   "createdbefore",
+}
+
+// dataSourceTickets defines the cloudportal_tickets data source which lists
+// tickets matching a set of filters
+func dataSourceTickets() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceTicketsRead,
+		Schema: map[string]*schema.Schema{
+			"status": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets in this status",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets of this type",
+			},
+			"cloudplatform": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets for this cloud platform",
+			},
+			"serviceprovider": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets for this service provider",
+			},
+			"createdbyemail": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets created by the user with this email address",
+			},
+			"claritycode": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return tickets booked on this clarity code",
+			},
+			"createdafter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only return tickets created at or after this RFC 3339 timestamp",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"createdbefore": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only return tickets created before this RFC 3339 timestamp",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Identifiers of the matching tickets",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"tickets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching tickets",
+				Elem:        ticketsummaryschema(),
+			},
+		},
+	}
+}
+
+// dataSourceTicketsRead lists the tickets matching the configured filters
+func dataSourceTicketsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	filter := url.Values{}
+	for attr, param := range ticketFilters {
+		if v, ok := d.GetOk(attr); ok {
+			filter.Set(param, v.(string))
+		}
+	}
+
+	tickets, err := client.ListTickets(ctx, filter)
+	if err != nil {
+		return diag.Errorf("error listing tickets: %s", err)
+	}
+
+	ids := make([]interface{}, 0, len(tickets))
+	for _, ticket := range tickets {
+		ids = append(ids, ticket.ID)
+	}
+
+	d.Set("ids", ids)
+	d.Set("tickets", flattenTicketSummaries(tickets))
+
+	d.SetId(strconv.Itoa(schema.HashString(filter.Encode())))
+
+	return nil
+}
+
+// Helper function to flatten tickets into their summary shape
+func flattenTicketSummaries(tickets []cloudportal.Ticket) []interface{} {
+	result := make([]interface{}, 0, len(tickets))
+	for _, ticket := range tickets {
+		result = append(result, map[string]interface{}{
+			"id":              ticket.ID,
+			"ticketno":        ticket.TicketNo,
+			"title":           ticket.Title,
+			"status":          ticket.Status,
+			"substatus":       ticket.SubStatus,
+			"type":            ticket.Type,
+			"serviceprovider": ticket.ServiceProvider,
+			"cloudplatform":   ticket.CloudPlatform,
+			"createdat":       ticket.CreatedAt,
+			"createdbyemail":  ticket.CreatedBy.Email,
+			"claritycode":     ticket.ClarityCode.Code,
+		})
+	}
+	return result
+}
@@ -1,41 +1,23 @@
 package provider
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"net/http"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
 )
 
-// CloudportalAPIClient represents a custom API client that communicates with the API
-type CloudportalAPIClient struct {
-	BaseURL   string
-	APIKey    string
-	Client    *http.Client
-	aziclient *azidentity.ClientSecretCredential
-	isdebug   bool
-	tenantID  string
-}
-
-// NewCloudportalAPIClient initializes a new API client
-func NewCloudportalAPIClient(azidentity *azidentity.ClientSecretCredential, apiKey, baseURL string, tenID string, debuginfo bool) *CloudportalAPIClient {
-	return &CloudportalAPIClient{
-		BaseURL:   baseURL,
-		APIKey:    apiKey,
-		Client:    &http.Client{},
-		aziclient: azidentity,
-		isdebug:   debuginfo,
-		tenantID:  tenID,
-	}
-}
-
 // providerConfigure initializes the custom API client
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	apiKey := d.Get("api_key").(string)
 	baseURL := d.Get("base_url").(string)
 	debugInfo := d.Get("debug_info").(bool)
@@ -51,23 +33,85 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	logger.Info("start")
 	if apiKey == "" || baseURL == "" {
 		logger.Error("API key and base URL must be provided")
-		return nil, fmt.Errorf("API key and base URL must be provided")
+		return nil, diag.Errorf("API key and base URL must be provided")
+	}
+
+	// Portal and token requests share the proxy and TLS settings
+	transport, err := cloudportal.NewTransport(transportConfigFromResourceData(d))
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, diag.Errorf("error configuring transport: %s", err)
+	}
+	var diags diag.Diagnostics
+	if d.Get("no_proxy").(string) != "" && d.Get("proxy_url").(string) == "" {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "no_proxy is ignored without proxy_url",
+			Detail:   "no_proxy only applies to proxy_url, proxies taken from HTTPS_PROXY and HTTP_PROXY honour NO_PROXY instead",
+		})
+	}
+
+	// The settings are checked here rather than with RequiredWith, which also
+	// counts values taken from the environment
+	tokenCachePath := d.Get("token_cache_path").(string)
+	tokenCacheKey := d.Get("token_cache_key").(string)
+	if tokenCachePath != "" && tokenCacheKey == "" {
+		return nil, diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Missing token_cache_key",
+			Detail:        "token_cache_key (or CLOUDPORTAL_TOKEN_CACHE_KEY) must be set to encrypt the token cache file in token_cache_path",
+			AttributePath: cty.GetAttrPath("token_cache_key"),
+		}}
+	}
+	if tokenCacheKey != "" && tokenCachePath == "" {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "token_cache_key is ignored without token_cache_path",
+			Detail:   "Tokens are only cached in memory unless token_cache_path (or CLOUDPORTAL_TOKEN_CACHE_PATH) is set",
+		})
+	}
+
+	requestTimeout, err := time.ParseDuration(d.Get("request_timeout").(string))
+	if err != nil {
+		return nil, diag.Errorf("error parsing request_timeout: %s", err)
 	}
 
-	// Define your Azure credentials
-	clientID := d.Get("clientID").(string)
-	clientSecret := d.Get("clientSecret").(string)
-	tenantID := d.Get("tenantID").(string)
+	config := credentialConfigFromResourceData(d)
+	scopes, err := cloudportal.TokenScopes(config.TenantID, d.Get("application_id_uri").(string), expandStringList(d.Get("scopes").([]interface{})))
+	if err != nil {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Missing token scope",
+			Detail:   "One of tenantID, application_id_uri or scopes must be set to request portal API tokens",
+		}}
+	}
 
-	// Use azidentity to authenticate using client credentials
-	client, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	// Use azidentity to authenticate with the configured auth method, a hung
+	// token endpoint must not outlast request_timeout either
+	config.Transport = &http.Client{Transport: transport, Timeout: requestTimeout}
+	client, err := cloudportal.NewCredential(config)
 	if err != nil {
 		logger.Error(err.Error())
+		return nil, diag.Errorf("error configuring %s authentication: %s", config.AuthMethod, err)
 	}
 
-	apiclient := NewCloudportalAPIClient(client, apiKey, baseURL, tenantID, debugInfo)
+	apiclient := cloudportal.NewCloudportalAPIClient(client, apiKey, baseURL, scopes, debugInfo)
+	apiclient.SetTransport(transport)
+	retryConfig, err := retryConfigFromResourceData(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	apiclient.SetRequestTimeout(requestTimeout)
+	apiclient.SetRetryConfig(retryConfig)
+	apiclient.SetRateLimit(cloudportal.RateLimitConfig{
+		MaxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+		RequestsPerSecond:     d.Get("requests_per_second").(float64),
+	})
+	if tokenCachePath != "" {
+		apiclient.UseTokenCacheFile(tokenCachePath, tokenCacheKey, config)
+	}
 
-	return apiclient, nil
+	return apiclient, diags
 }
 
 func Provider() *schema.Provider {
@@ -77,48 +121,243 @@ func Provider() *schema.Provider {
 			"api_key": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "API key for authenticating with the custom API",
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_API_KEY", nil),
+				Description: "API key for authenticating with the custom API, defaults to CLOUDPORTAL_API_KEY",
 			},
 			"base_url": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "Base URL of the custom API",
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_BASE_URL", nil),
+				Description: "Base URL of the custom API, defaults to CLOUDPORTAL_BASE_URL",
 			},
 			"debug_info": {
 				Type:        schema.TypeBool,
-				Required:    true,
-				Description: "Debug infor mation logging",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_DEBUG_INFO", false),
+				Description: "Debug information logging, defaults to CLOUDPORTAL_DEBUG_INFO or false",
+			},
+			"auth_method": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_AUTH_METHOD", cloudportal.AuthMethodClientSecret),
+				ValidateFunc: validation.StringInSlice(cloudportal.AuthMethods, false),
+				Description:  "How to authenticate with the custom API: client_secret, client_certificate, managed_identity, workload_identity, azure_cli or default, defaults to CLOUDPORTAL_AUTH_METHOD or client_secret",
 			},
 			"clientID": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "clientID key for authenticating with the custom API",
+				Optional:    true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_CLIENT_ID", "AZURE_CLIENT_ID"}, nil),
+				Description: "clientID key for authenticating with the custom API, selects the user-assigned identity for managed_identity, defaults to CLOUDPORTAL_CLIENT_ID or AZURE_CLIENT_ID",
 			},
 			"clientSecret": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "clientSecret key for authenticating with the custom API",
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_CLIENT_SECRET", "AZURE_CLIENT_SECRET"}, nil),
+				Description: "clientSecret key for authenticating with the custom API, required for client_secret, defaults to CLOUDPORTAL_CLIENT_SECRET or AZURE_CLIENT_SECRET",
 			},
 			"tenantID": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "tenantID key for authenticating with the custom API",
+				Optional:    true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_TENANT_ID", "AZURE_TENANT_ID"}, nil),
+				Description: "tenantID key for authenticating with the custom API, defaults to CLOUDPORTAL_TENANT_ID or AZURE_TENANT_ID",
+			},
+			"client_certificate_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_CLIENT_CERTIFICATE_PATH", "AZURE_CLIENT_CERTIFICATE_PATH"}, nil),
+				Description: "Path of the PEM or PKCS#12 client certificate, required for client_certificate, defaults to CLOUDPORTAL_CLIENT_CERTIFICATE_PATH or AZURE_CLIENT_CERTIFICATE_PATH",
+			},
+			"client_certificate_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_CLIENT_CERTIFICATE_PASSWORD", "AZURE_CLIENT_CERTIFICATE_PASSWORD"}, nil),
+				Description: "Password of the client certificate, defaults to CLOUDPORTAL_CLIENT_CERTIFICATE_PASSWORD or AZURE_CLIENT_CERTIFICATE_PASSWORD",
+			},
+			"oidc_token_file_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_OIDC_TOKEN_FILE_PATH", "AZURE_FEDERATED_TOKEN_FILE"}, nil),
+				Description: "Path of the federated OIDC token file used by workload_identity, defaults to CLOUDPORTAL_OIDC_TOKEN_FILE_PATH or AZURE_FEDERATED_TOKEN_FILE",
+			},
+			"application_id_uri": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CLOUDPORTAL_APPLICATION_ID_URI", nil),
+				ConflictsWith: []string{"scopes"},
+				Description:   "Application ID URI of the portal API, tokens are requested for its /.default scope. Defaults to CLOUDPORTAL_APPLICATION_ID_URI or the tenantID",
+			},
+			"scopes": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"application_id_uri"},
+				Description:   "Scopes requested for portal API tokens, overrides application_id_uri",
+				Elem:          &schema.Schema{Type: schema.TypeString},
+			},
+			"authority_host": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"CLOUDPORTAL_AUTHORITY_HOST", "AZURE_AUTHORITY_HOST"}, nil),
+				Description: "Microsoft Entra authority: public, usgovernment, china or the https URL of a custom authority. Defaults to CLOUDPORTAL_AUTHORITY_HOST or AZURE_AUTHORITY_HOST, otherwise public",
+			},
+			"request_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_REQUEST_TIMEOUT", cloudportal.DefaultRequestTimeout.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum time a single portal API call may take including its retries (e.g. 2m), also limits token requests. 0 disables the timeout. Defaults to CLOUDPORTAL_REQUEST_TIMEOUT or 2m",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_MAX_RETRIES", cloudportal.DefaultRetryConfig.MaxRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "How often a request failing with a network error, 429 or 5xx status is retried, 0 disables retries. Defaults to CLOUDPORTAL_MAX_RETRIES or 3",
+			},
+			"retry_min_wait": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_RETRY_MIN_WAIT", cloudportal.DefaultRetryConfig.MinWait.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Wait before the first retry (e.g. 1s), doubled for every further retry. Defaults to CLOUDPORTAL_RETRY_MIN_WAIT or 1s",
+			},
+			"retry_max_wait": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_RETRY_MAX_WAIT", cloudportal.DefaultRetryConfig.MaxWait.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum wait between retries (e.g. 30s), a longer Retry-After of the API is still honoured. Defaults to CLOUDPORTAL_RETRY_MAX_WAIT or 30s",
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_MAX_CONCURRENT_REQUESTS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of portal API requests in flight at the same time across all resources, 0 means unlimited. Defaults to CLOUDPORTAL_MAX_CONCURRENT_REQUESTS or 0",
+			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_REQUESTS_PER_SECOND", 0.0),
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Maximum sustained rate of portal API requests across all resources, 0 means unlimited. Time spent waiting is logged when debug_info is set. Defaults to CLOUDPORTAL_REQUESTS_PER_SECOND or 0",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_PROXY_URL", nil),
+				Description: "Proxy for portal API and token requests (e.g. http://proxy.example.com:8080), defaults to CLOUDPORTAL_PROXY_URL, otherwise HTTPS_PROXY and NO_PROXY are honoured",
+			},
+			"no_proxy": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_NO_PROXY", nil),
+				Description: "Comma separated hosts, domains and CIDRs reached without proxy_url, ignored without proxy_url. Link-local addresses such as the managed identity endpoint are never proxied. Defaults to CLOUDPORTAL_NO_PROXY",
+			},
+			"ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_CA_CERT_FILE", nil),
+				Description: "PEM file of root certificates trusted in addition to the system roots, e.g. for TLS inspection. Defaults to CLOUDPORTAL_CA_CERT_FILE",
+			},
+			"ca_cert_pem": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_CA_CERT_PEM", nil),
+				Description: "PEM encoded root certificates trusted in addition to the system roots, defaults to CLOUDPORTAL_CA_CERT_PEM",
+			},
+			"tls_client_cert_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_TLS_CLIENT_CERT_FILE", nil),
+				RequiredWith: []string{"tls_client_key_file"},
+				Description:  "PEM client certificate presented for mutual TLS, defaults to CLOUDPORTAL_TLS_CLIENT_CERT_FILE",
+			},
+			"tls_client_key_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_TLS_CLIENT_KEY_FILE", nil),
+				RequiredWith: []string{"tls_client_cert_file"},
+				Description:  "PEM private key of tls_client_cert_file, defaults to CLOUDPORTAL_TLS_CLIENT_KEY_FILE",
+			},
+			"min_tls_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDPORTAL_MIN_TLS_VERSION", "1.2"),
+				ValidateFunc: validation.StringInSlice([]string{"1.2", "1.3"}, false),
+				Description:  "Minimum TLS version of portal API and token requests: 1.2 or 1.3, defaults to CLOUDPORTAL_MIN_TLS_VERSION or 1.2",
+			},
+			"token_cache_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_TOKEN_CACHE_PATH", nil),
+				Description: "File in which access tokens are kept encrypted between runs, requires token_cache_key. Defaults to CLOUDPORTAL_TOKEN_CACHE_PATH. Tokens are only cached in memory when not set",
+			},
+			"token_cache_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDPORTAL_TOKEN_CACHE_KEY", nil),
+				Description: "Passphrase the token cache file is encrypted with, ignored without token_cache_path. Defaults to CLOUDPORTAL_TOKEN_CACHE_KEY",
 			},
 		},
 		// Configure the provider with API credentials
-		ConfigureFunc: providerConfigure,
+		ConfigureContextFunc: providerConfigure,
 
 		// Define the resources and data sources
-		/*ResourcesMap: map[string]*schema.Resource{
-			"cloudportal_api_resource": ResourceCustom(),
-		},
-
-		// Map resources and data sources
 		ResourcesMap: map[string]*schema.Resource{
-			"cloudportal_resource": ResourceCustom(),
-		},*/
+			"cloudportal_ticket":             resourceTicket(),
+			"cloudportal_ticket_action":      resourceTicketAction(),
+			"cloudportal_ticket_comment":     resourceTicketComment(),
+			"cloudportal_ticket_attachment":  resourceTicketAttachment(),
+			"cloudportal_ticket_participant": resourceTicketParticipant(),
+		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"cloudportal_datasource": dataSourceTicket(), // Add data source here
+			"cloudportal_datasource":           dataSourceTicket(), // Add data source here
+			"cloudportal_tickets":              dataSourceTickets(),
+			"cloudportal_catalog_items":        dataSourceCatalogItems(),
+			"cloudportal_catalog_item":         dataSourceCatalogItem(),
+			"cloudportal_catalog_field_lookup": dataSourceCatalogFieldLookup(),
+			"cloudportal_ticket_attachment":    dataSourceTicketAttachment(),
+			"cloudportal_clarity_code":         dataSourceClarityCode(),
+			"cloudportal_clarity_codes":        dataSourceClarityCodes(),
+			"cloudportal_billing":              dataSourceBilling(),
+			"cloudportal_ticket_history":       dataSourceTicketHistory(),
 		},
 	}
 }
+
+// retryConfigFromResourceData reads the retry settings of the provider
+func retryConfigFromResourceData(d *schema.ResourceData) (cloudportal.RetryConfig, error) {
+	minWait, err := time.ParseDuration(d.Get("retry_min_wait").(string))
+	if err != nil {
+		return cloudportal.RetryConfig{}, fmt.Errorf("error parsing retry_min_wait: %s", err)
+	}
+	maxWait, err := time.ParseDuration(d.Get("retry_max_wait").(string))
+	if err != nil {
+		return cloudportal.RetryConfig{}, fmt.Errorf("error parsing retry_max_wait: %s", err)
+	}
+	if maxWait < minWait {
+		return cloudportal.RetryConfig{}, fmt.Errorf("retry_max_wait must not be shorter than retry_min_wait")
+	}
+
+	return cloudportal.RetryConfig{
+		MaxRetries: d.Get("max_retries").(int),
+		MinWait:    minWait,
+		MaxWait:    maxWait,
+	}, nil
+}
+
+// validateDuration checks that a setting is a non-negative Go duration such as 30s
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	duration, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%q must be a duration such as 30s or 1m: %s", k, err)}
+	}
+	if duration < 0 {
+		return nil, []error{fmt.Errorf("%q must not be negative", k)}
+	}
+	return nil, nil
+}
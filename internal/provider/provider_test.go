@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// The provider settings predate the naming rules (clientID etc.), so only the
+// resources and data sources are validated
+func TestProviderResources(t *testing.T) {
+	p := Provider()
+	for name, r := range p.ResourcesMap {
+		if err := r.InternalValidate(p.Schema, true); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+	for name, r := range p.DataSourcesMap {
+		if err := r.InternalValidate(p.Schema, false); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+}
+
+// testProviderData returns the provider settings for the minimal configuration
+func testProviderData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{"api_key": "key", "base_url": "https://portal.example.com"})
+}
+
+func TestProviderRetryConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"defaults", nil, "3 1s 30s"},
+		{"environment", map[string]string{
+			"CLOUDPORTAL_MAX_RETRIES":    "5",
+			"CLOUDPORTAL_RETRY_MIN_WAIT": "2s",
+			"CLOUDPORTAL_RETRY_MAX_WAIT": "1m",
+		}, "5 2s 1m0s"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			config, err := retryConfigFromResourceData(testProviderData(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%d %s %s", config.MaxRetries, config.MinWait, config.MaxWait); got != tc.want {
+				t.Errorf("retry config = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProviderRateLimitSettings(t *testing.T) {
+	cases := []struct {
+		name           string
+		env            map[string]string
+		wantConcurrent int
+		wantRate       float64
+	}{
+		{"defaults", nil, 0, 0},
+		{"environment", map[string]string{
+			"CLOUDPORTAL_MAX_CONCURRENT_REQUESTS": "4",
+			"CLOUDPORTAL_REQUESTS_PER_SECOND":     "2.5",
+		}, 4, 2.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			d := testProviderData(t)
+			if got := d.Get("max_concurrent_requests").(int); got != tc.wantConcurrent {
+				t.Errorf("max_concurrent_requests = %d, want %d", got, tc.wantConcurrent)
+			}
+			if got := d.Get("requests_per_second").(float64); got != tc.wantRate {
+				t.Errorf("requests_per_second = %v, want %v", got, tc.wantRate)
+			}
+		})
+	}
+}
+
+func TestProviderRequestTimeout(t *testing.T) {
+	if got := testProviderData(t).Get("request_timeout"); got != "2m0s" {
+		t.Errorf("request_timeout = %v, want 2m0s", got)
+	}
+
+	t.Setenv("CLOUDPORTAL_REQUEST_TIMEOUT", "45s")
+	if got := testProviderData(t).Get("request_timeout"); got != "45s" {
+		t.Errorf("request_timeout = %v, want 45s", got)
+	}
+}
+
+// staticCredential hands out a fixed access token
+type staticCredential struct{}
+
+func (staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// newTestClient returns a client for a fake portal served by handler
+func newTestClient(t *testing.T, handler http.Handler) *cloudportal.CloudportalAPIClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := cloudportal.NewCloudportalAPIClient(staticCredential{}, "key", server.URL, []string{"api://portal/.default"}, false)
+	client.SetRetryConfig(cloudportal.RetryConfig{})
+	return client
+}
+
+// jsonHandler answers every request with value encoded as JSON
+func jsonHandler(value interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(value)
+	}
+}
+
+func TestProviderConfigureTokenScope(t *testing.T) {
+	t.Setenv("CLOUDPORTAL_TENANT_ID", "")
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("CLOUDPORTAL_APPLICATION_ID_URI", "")
+
+	_, diags := providerConfigure(context.Background(), testProviderData(t))
+	if !diags.HasError() {
+		t.Fatal("expected an error without tenantID, application_id_uri or scopes")
+	}
+	if detail := diags[0].Detail; !strings.Contains(detail, "tenantID") || !strings.Contains(detail, "application_id_uri") {
+		t.Errorf("detail = %q, want it to name tenantID and application_id_uri", detail)
+	}
+}
+
+func TestProviderConfigureTokenCache(t *testing.T) {
+	cases := []struct {
+		name        string
+		env         map[string]string
+		wantError   string
+		wantWarning string
+	}{
+		{"path without key", map[string]string{"CLOUDPORTAL_TOKEN_CACHE_PATH": "tokens"}, "token_cache_key", ""},
+		{"key without path", map[string]string{"CLOUDPORTAL_TOKEN_CACHE_KEY": "secret"}, "", "token_cache_key is ignored"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CLOUDPORTAL_TOKEN_CACHE_PATH", "")
+			t.Setenv("CLOUDPORTAL_TOKEN_CACHE_KEY", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+				"api_key":      "key",
+				"base_url":     "https://portal.example.com",
+				"tenantID":     "tenant",
+				"clientID":     "client",
+				"clientSecret": "secret",
+			})
+			_, diags := providerConfigure(context.Background(), d)
+
+			summaries := map[diag.Severity]string{}
+			for _, item := range diags {
+				summaries[item.Severity] += item.Summary
+			}
+			if got := summaries[diag.Error]; (tc.wantError == "") != (got == "") || !strings.Contains(got, tc.wantError) {
+				t.Errorf("errors = %q, want %q", got, tc.wantError)
+			}
+			if got := summaries[diag.Warning]; (tc.wantWarning == "") != (got == "") || !strings.Contains(got, tc.wantWarning) {
+				t.Errorf("warnings = %q, want %q", got, tc.wantWarning)
+			}
+		})
+	}
+}
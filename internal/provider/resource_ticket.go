@@ -0,0 +1,353 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// resourceTicket defines the cloudportal_ticket resource used to request
+// cloud resources from the portal
+func resourceTicket() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTicketCreate,
+		ReadContext:   resourceTicketRead,
+		UpdateContext: resourceTicketUpdate,
+		DeleteContext: resourceTicketDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceTicketImport,
+		},
+		// Sequence keeps the cty.PathError of a finding intact, All would join it away
+		CustomizeDiff: customdiff.Sequence(
+			resourceTicketCatalogFieldsDiff,
+			resourceTicketClarityCodeDiff,
+			resourceTicketCatalogItemsDiff,
+		),
+		Schema: addTicketWaitSchema(ticketResourceSchema()),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+	}
+}
+
+// resourceTicketCreate raises a new ticket in the portal
+func resourceTicketCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	properties := make(map[string]interface{})
+	for _, key := range ticketInputFields {
+		if v, ok := d.GetOk(key); ok {
+			properties[key] = expandTicketProperty(key, v)
+		}
+	}
+
+	ticket, err := client.CreateTicket(ctx, properties)
+	if err != nil {
+		return diag.Errorf("error creating ticket: %s", err)
+	}
+	if ticket.ID == "" {
+		return diag.Errorf("error creating ticket: API returned no ticket id")
+	}
+
+	logger.Info("Created ticket " + ticket.ID)
+	d.SetId(ticket.ID)
+
+	if _, err := waitForTicketStatus(ctx, client, d, ticket.ID, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceTicketRead(ctx, d, meta)
+}
+
+// resourceTicketRead reads the state of the ticket from the portal
+func resourceTicketRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticket, err := client.GetTicket(ctx, d.Id())
+	if err != nil {
+		if cloudportal.IsNotFound(err) {
+			logger.Info("Ticket " + d.Id() + " not found, removing from state")
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading ticket %s: %s", d.Id(), err)
+	}
+
+	configured := d.Get("catalogitems").([]interface{})
+	setTicketData(d, ticket)
+	d.Set("catalogitems", flattenConfiguredCatalogItems(ticket.CatalogItems, configured))
+
+	return nil
+}
+
+// resourceTicketUpdate patches the changed properties of the ticket. Only
+// properties the portal currently lists as editable can be changed.
+func resourceTicketUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	current, err := client.GetTicket(ctx, d.Id())
+	if err != nil {
+		return diag.Errorf("error reading ticket %s: %s", d.Id(), err)
+	}
+
+	properties := make(map[string]interface{})
+	for _, key := range ticketInputFields {
+		if !d.HasChange(key) {
+			continue
+		}
+		if !isEditable(current.EditableProperties, key) {
+			return diag.Errorf("property %q of ticket %s cannot be changed while the ticket is in status %q", key, d.Id(), current.Status)
+		}
+		properties[key] = expandTicketProperty(key, d.Get(key))
+	}
+
+	if len(properties) > 0 {
+		if _, err := client.UpdateTicket(ctx, d.Id(), current.ETag, properties); err != nil {
+			return diag.Errorf("error updating ticket %s: %s", d.Id(), err)
+		}
+	}
+
+	if _, err := waitForTicketStatus(ctx, client, d, d.Id(), d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceTicketRead(ctx, d, meta)
+}
+
+// resourceTicketDelete cancels the ticket in the portal
+func resourceTicketDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	if err := client.DeleteTicket(ctx, d.Id()); err != nil && !cloudportal.IsNotFound(err) {
+		return diag.Errorf("error deleting ticket %s: %s", d.Id(), err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceTicketClarityCodeDiff rejects clarity codes unknown to the portal at plan time
+func resourceTicketClarityCodeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange("claritycode") || !d.NewValueKnown("claritycode") {
+		return nil
+	}
+
+	code := d.Get("claritycode.0.code").(string)
+	if code == "" {
+		return nil
+	}
+
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	if _, err := client.GetClarityCode(ctx, code); err != nil {
+		if cloudportal.IsNotFound(err) {
+			return cty.GetAttrPath("claritycode").IndexInt(0).GetAttr("code").NewErrorf("clarity code %q does not exist", code)
+		}
+		return fmt.Errorf("error reading clarity code %s: %s", code, err)
+	}
+
+	return nil
+}
+
+// resourceTicketCatalogFieldsDiff drops the diff of catalog fields when every
+// configured field is tracked with the configured value. An imported ticket
+// tracks all fields the portal returns, which otherwise shows up as a diff
+// removing the fields that are not configured.
+func resourceTicketCatalogFieldsDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.HasChange("catalogitems") || !d.NewValueKnown("catalogitems") {
+		return nil
+	}
+
+	o, n := d.GetChange("catalogitems")
+	old, configured := o.([]interface{}), n.([]interface{})
+	for i := 0; i < len(old) && i < len(configured); i++ {
+		if old[i] == nil || configured[i] == nil {
+			continue
+		}
+		oldItem, item := old[i].(map[string]interface{}), configured[i].(map[string]interface{})
+		key := fmt.Sprintf("catalogitems.%d.catalogfields", i)
+		if oldItem["name"] != item["name"] || !d.HasChange(key) || !d.NewValueKnown(key) {
+			continue
+		}
+		if catalogFieldsTracked(item["catalogfields"].([]interface{}), oldItem["catalogfields"].([]interface{})) {
+			if err := d.Clear(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// catalogFieldsTracked reports whether every configured catalog field is
+// tracked with the same value, in any order
+func catalogFieldsTracked(configured, tracked []interface{}) bool {
+	values := make(map[string]string)
+	for _, v := range tracked {
+		if v == nil {
+			continue
+		}
+		m := v.(map[string]interface{})
+		values[m["key"].(string)] = m["value"].(string)
+	}
+	for _, v := range configured {
+		if v == nil {
+			return false
+		}
+		m := v.(map[string]interface{})
+		if value, ok := values[m["key"].(string)]; !ok || value != m["value"].(string) {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceTicketImport imports an existing ticket by its id or, when the
+// import id is numeric, by its ticket number
+func resourceTicketImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	id := d.Id()
+	if ticketNo, convErr := strconv.Atoi(id); convErr == nil {
+		found, err := client.FindTicketByNumber(ctx, ticketNo)
+		if err != nil {
+			return nil, fmt.Errorf("error importing ticket %s: %s", d.Id(), err)
+		}
+		id = found.ID
+	}
+
+	ticket, err := client.GetTicket(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error importing ticket %s: %s", d.Id(), err)
+	}
+
+	d.SetId(ticket.ID)
+	setTicketData(d, ticket)
+	d.Set("catalogitems", flattenConfiguredCatalogItems(ticket.CatalogItems, nil))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// flattenConfiguredCatalogItems flattens the catalog items of a ticket for the
+// cloudportal_ticket resource. The portal returns every field of a catalog
+// item, catalogfields only keeps the fields that are configured, in config
+// order, while catalogfielddefinitions holds all of them. Catalog items that
+// are not configured, as on import, keep all their fields, see
+// resourceTicketCatalogFieldsDiff.
+func flattenConfiguredCatalogItems(catalogItems []cloudportal.CatalogItem, configured []interface{}) []interface{} {
+	result := flattenCatalogItems(catalogItems)
+	for i, item := range catalogItems {
+		m := result[i].(map[string]interface{})
+		m["catalogfielddefinitions"] = m["catalogfields"]
+
+		if i >= len(configured) || configured[i] == nil {
+			continue
+		}
+		c := configured[i].(map[string]interface{})
+		if c["name"].(string) != item.Name {
+			continue
+		}
+		fields, _ := c["catalogfields"].([]interface{})
+
+		values := make(map[string]cloudportal.CatalogField)
+		for _, field := range item.CatalogFields {
+			values[field.Key] = field
+		}
+		var kept []cloudportal.CatalogField
+		for _, v := range fields {
+			if v == nil {
+				continue
+			}
+			if field, ok := values[v.(map[string]interface{})["key"].(string)]; ok {
+				kept = append(kept, field)
+			}
+		}
+		m["catalogfields"] = flattenCatalogFields(kept)
+	}
+	return result
+}
+
+// parseTicketChildID splits the id of an object that belongs to a ticket, such
+// as a comment, into the ticket id and the id of the object
+func parseTicketChildID(id string) (string, string, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected format of id %q, expected <ticket-id>/<id>", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+// isEditable reports whether the property is listed in the ticket's editable properties
+func isEditable(editableProperties []string, key string) bool {
+	for _, property := range editableProperties {
+		if strings.EqualFold(property, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTicketProperty converts a configured ticket property into its API representation
+func expandTicketProperty(key string, v interface{}) interface{} {
+	switch key {
+	case "claritycode":
+		return expandClarityCode(v.([]interface{}))
+	case "catalogitems":
+		return expandCatalogItems(v.([]interface{}))
+	default:
+		return v
+	}
+}
+
+// Helper function to expand the clarity code block
+func expandClarityCode(list []interface{}) map[string]interface{} {
+	if len(list) == 0 || list[0] == nil {
+		return nil
+	}
+	m := list[0].(map[string]interface{})
+	return map[string]interface{}{
+		"code": m["code"].(string),
+	}
+}
+
+// Helper function to expand catalog items
+func expandCatalogItems(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		m := v.(map[string]interface{})
+		item := map[string]interface{}{
+			"name":          m["name"].(string),
+			"catalogfields": expandCatalogFields(m["catalogfields"].([]interface{})),
+		}
+		if version := m["catalogitemversion"].(int); version != 0 {
+			item["catalogitemversion"] = version
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// Helper function to expand catalog fields
+func expandCatalogFields(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		m := v.(map[string]interface{})
+		result = append(result, map[string]interface{}{
+			"key":   m["key"].(string),
+			"value": m["value"].(string),
+		})
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// resourceTicketAction defines the cloudportal_ticket_action resource which
+// submits a workflow action (approve, reject, submit, close...) on a ticket.
+// Actions cannot be undone, so every argument forces a new action and
+// destroying the resource only removes it from state.
+func resourceTicketAction() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTicketActionCreate,
+		ReadContext:   resourceTicketActionRead,
+		DeleteContext: resourceTicketActionDelete,
+		CustomizeDiff: resourceTicketActionCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Unique identifier of the ticket the action is submitted on",
+			},
+			"actionname": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the action, must be one of the ticket's valid actions",
+			},
+			"properties": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Properties submitted with the action, must contain the action's required properties",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of action",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Status of the ticket after the action was submitted",
+			},
+			"substatus": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Sub-status of the ticket after the action was submitted",
+			},
+		},
+	}
+}
+
+// resourceTicketActionCustomizeDiff validates the action against the ticket's
+// current valid actions at plan time
+func resourceTicketActionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// Existing actions are not re-validated, but changing any argument submits
+	// a replacement action which has to be valid before the old one is gone
+	if d.Id() != "" && !d.HasChanges("ticketid", "actionname", "properties") {
+		return nil
+	}
+
+	// The ticket may not exist yet when it is created in the same plan
+	if !d.NewValueKnown("ticketid") || !d.NewValueKnown("actionname") || !d.NewValueKnown("properties") {
+		return nil
+	}
+
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	action, err := validateTicketAction(ticket, d.Get("actionname").(string), expandStringMap(d.Get("properties").(map[string]interface{})))
+	if err != nil {
+		return err
+	}
+
+	return d.SetNew("type", action.Type)
+}
+
+// resourceTicketActionCreate submits the action on the ticket
+func resourceTicketActionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID := d.Get("ticketid").(string)
+	actionName := d.Get("actionname").(string)
+	properties := expandStringMap(d.Get("properties").(map[string]interface{}))
+
+	// Validate again, the ticket may have moved on since the plan was made
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+	action, err := validateTicketAction(ticket, actionName, properties)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	result, err := client.SubmitTicketAction(ctx, ticketID, ticket.ETag, action.ActionName, properties)
+	if err != nil {
+		return diag.Errorf("error submitting action %q on ticket %s: %s", actionName, ticketID, err)
+	}
+
+	// Some actions do not return the ticket, read it back to get the new status
+	if result.ID == "" {
+		result, err = client.GetTicket(ctx, ticketID)
+		if err != nil {
+			return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+		}
+	}
+
+	logger.Info("Submitted action " + actionName + " on ticket " + ticketID + ", status is now " + result.Status)
+
+	d.SetId(ticketID + "/" + actionName)
+	d.Set("type", action.Type)
+	d.Set("status", result.Status)
+	d.Set("substatus", result.SubStatus)
+
+	return nil
+}
+
+// resourceTicketActionRead only checks that the ticket still exists, the
+// recorded status is the one at the time the action was submitted
+func resourceTicketActionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+
+	if _, err := client.GetTicket(ctx, ticketID); err != nil {
+		if cloudportal.IsNotFound(err) {
+			logger.Info("Ticket " + ticketID + " not found, removing action from state")
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	return nil
+}
+
+// resourceTicketActionDelete removes the action from state, submitted actions
+// cannot be reverted in the portal
+func resourceTicketActionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+// validateTicketAction checks that the action is currently valid for the
+// ticket and that all of its required properties are supplied
+func validateTicketAction(ticket *cloudportal.Ticket, actionName string, properties map[string]string) (*cloudportal.Action, error) {
+	var action *cloudportal.Action
+	var names []string
+	for i := range ticket.ValidActions {
+		names = append(names, ticket.ValidActions[i].ActionName)
+		if strings.EqualFold(ticket.ValidActions[i].ActionName, actionName) {
+			action = &ticket.ValidActions[i]
+		}
+	}
+	if action == nil {
+		sort.Strings(names)
+		return nil, fmt.Errorf("action %q is not valid for ticket %s in status %q, valid actions are: %s", actionName, ticket.ID, ticket.Status, strings.Join(names, ", "))
+	}
+
+	var missing []string
+	for _, property := range action.RequiredProperties {
+		if properties[property] == "" {
+			missing = append(missing, property)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("action %q on ticket %s requires properties: %s", action.ActionName, ticket.ID, strings.Join(missing, ", "))
+	}
+
+	if len(ticket.CatalogItems) < action.MinNumOfCatalogItems {
+		return nil, fmt.Errorf("action %q on ticket %s requires at least %d catalog items, the ticket has %d", action.ActionName, ticket.ID, action.MinNumOfCatalogItems, len(ticket.CatalogItems))
+	}
+
+	return action, nil
+}
+
+// Helper function to expand a map of strings from configuration
+func expandStringMap(m map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v.(string)
+	}
+	return result
+}
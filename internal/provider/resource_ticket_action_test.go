@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+func TestResourceTicketActionCustomizeDiff(t *testing.T) {
+	client := newTestClient(t, jsonHandler(cloudportal.Ticket{
+		ID:     "t1",
+		Status: "Submitted",
+		ValidActions: []cloudportal.Action{
+			{ActionName: "approve", Type: "approval"},
+			{ActionName: "reject", Type: "approval", RequiredProperties: []string{"reason"}},
+		},
+	}))
+	existing := &terraform.InstanceState{
+		ID:         "t1/approve",
+		Attributes: map[string]string{"id": "t1/approve", "ticketid": "t1", "actionname": "approve", "properties.%": "0", "type": "approval"},
+	}
+
+	cases := []struct {
+		name    string
+		state   *terraform.InstanceState
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "valid new action",
+			config: map[string]interface{}{"ticketid": "t1", "actionname": "approve"},
+		},
+		{
+			name:    "invalid new action",
+			config:  map[string]interface{}{"ticketid": "t1", "actionname": "close"},
+			wantErr: `action "close" is not valid`,
+		},
+		{
+			name:   "unchanged existing action is not re-validated",
+			state:  existing,
+			config: map[string]interface{}{"ticketid": "t1", "actionname": "approve"},
+		},
+		{
+			name:    "replacement with an invalid action",
+			state:   existing,
+			config:  map[string]interface{}{"ticketid": "t1", "actionname": "close"},
+			wantErr: `action "close" is not valid`,
+		},
+		{
+			name:    "replacement missing required properties",
+			state:   existing,
+			config:  map[string]interface{}{"ticketid": "t1", "actionname": "reject"},
+			wantErr: "requires properties: reason",
+		},
+		{
+			name:   "valid replacement",
+			state:  existing,
+			config: map[string]interface{}{"ticketid": "t1", "actionname": "reject", "properties": map[string]interface{}{"reason": "duplicate"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resourceTicketAction().Diff(context.Background(), tc.state, terraform.NewResourceConfigRaw(tc.config), client)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// resourceTicketAttachment defines the cloudportal_ticket_attachment resource
+// which uploads a local file or inline content to a ticket. The id has the
+// form <ticket-id>/<filename>.
+func resourceTicketAttachment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTicketAttachmentCreate,
+		ReadContext:   resourceTicketAttachmentRead,
+		DeleteContext: resourceTicketAttachmentDelete,
+		CustomizeDiff: resourceTicketAttachmentDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Unique identifier of the ticket the file is attached to",
+			},
+			"filename": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name of the attachment file, defaults to the name of the source file",
+			},
+			"source": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"source", "content"},
+				Description:  "Path of the local file to upload",
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"source", "content"},
+				Description:  "Inline content to upload",
+			},
+			"contentsha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 hash of the attachment content, used to detect changes",
+			},
+			"url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "URL of the attachment",
+			},
+			"uploaddatetime": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Upload timestamp of the attachment",
+			},
+			"uploadedby": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     userschema(),
+			},
+		},
+	}
+}
+
+// resourceTicketAttachmentDiff hashes the local content during plan, so a
+// changed source file or an attachment replaced in the portal is re-uploaded
+func resourceTicketAttachmentDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if err := validateAttachmentFilename(d.GetRawConfig()); err != nil {
+		return err
+	}
+
+	if !d.NewValueKnown("source") || !d.NewValueKnown("content") {
+		return d.SetNewComputed("contentsha256")
+	}
+
+	content, err := attachmentContent(d.Get("source").(string), d.Get("content").(string))
+	if err != nil {
+		return err
+	}
+
+	hash := contentSHA256(content)
+	if hash == d.Get("contentsha256").(string) {
+		return nil
+	}
+
+	if err := d.SetNew("contentsha256", hash); err != nil {
+		return err
+	}
+	if d.Id() != "" {
+		return d.ForceNew("contentsha256")
+	}
+	return nil
+}
+
+// validateAttachmentFilename rejects inline content without a filename, only
+// a source file provides a name to fall back to
+func validateAttachmentFilename(raw cty.Value) error {
+	if raw.IsNull() || !raw.IsKnown() {
+		return nil
+	}
+	if raw.GetAttr("source").IsNull() && raw.GetAttr("filename").IsNull() {
+		return cty.GetAttrPath("filename").NewErrorf("filename must be set when uploading inline content")
+	}
+	return nil
+}
+
+// resourceTicketAttachmentCreate uploads the file to the ticket
+func resourceTicketAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+	source := d.Get("source").(string)
+
+	content, err := attachmentContent(source, d.Get("content").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Inline content without a filename is rejected at plan time
+	filename := d.Get("filename").(string)
+	if filename == "" {
+		filename = filepath.Base(source)
+	}
+
+	if _, err := client.UploadAttachment(ctx, ticketID, filename, content); err != nil {
+		return diag.Errorf("error uploading %s to ticket %s: %s", filename, ticketID, err)
+	}
+
+	logger.Info("Uploaded " + filename + " to ticket " + ticketID)
+	d.SetId(ticketID + "/" + filename)
+
+	return resourceTicketAttachmentRead(ctx, d, meta)
+}
+
+// resourceTicketAttachmentRead reads the attachment metadata from the ticket
+// and hashes the stored content to detect changes made in the portal
+func resourceTicketAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, filename, err := parseTicketChildID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		if cloudportal.IsNotFound(err) {
+			logger.Info("Ticket " + ticketID + " not found, removing attachment from state")
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	attachment := findAttachment(ticket.Attachments, filename)
+	if attachment == nil {
+		logger.Info("Attachment " + d.Id() + " not found, removing from state")
+		d.SetId("")
+		return nil
+	}
+
+	content, err := client.DownloadAttachment(ctx, ticketID, filename)
+	if err != nil {
+		return diag.Errorf("error downloading %s from ticket %s: %s", filename, ticketID, err)
+	}
+
+	d.Set("ticketid", ticketID)
+	d.Set("filename", attachment.Filename)
+	d.Set("contentsha256", contentSHA256(content))
+	d.Set("url", attachment.URL)
+	d.Set("uploaddatetime", attachment.UploadDateTime)
+	d.Set("uploadedby", flattenUsers(attachment.UploadedBy))
+
+	return nil
+}
+
+// resourceTicketAttachmentDelete removes the attachment from the ticket
+func resourceTicketAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, filename, err := parseTicketChildID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := client.DeleteAttachment(ctx, ticketID, filename); err != nil && !cloudportal.IsNotFound(err) {
+		return diag.Errorf("error deleting %s from ticket %s: %s", filename, ticketID, err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// attachmentContent returns the content to upload from either the source file
+// or the inline content
+func attachmentContent(source, content string) ([]byte, error) {
+	if source == "" {
+		return []byte(content), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", source, err)
+	}
+	return data, nil
+}
+
+// findAttachment returns the attachment with the given filename, if any
+func findAttachment(attachments []cloudportal.Attachment, filename string) *cloudportal.Attachment {
+	for i := range attachments {
+		if attachments[i].Filename == filename {
+			return &attachments[i]
+		}
+	}
+	return nil
+}
+
+// contentSHA256 returns the hex encoded SHA-256 hash of the content
+func contentSHA256(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidateAttachmentFilename(t *testing.T) {
+	config := func(source, content, filename cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{"source": source, "content": content, "filename": filename})
+	}
+	null := cty.NullVal(cty.String)
+
+	cases := []struct {
+		name    string
+		raw     cty.Value
+		wantErr bool
+	}{
+		{"source without filename", config(cty.StringVal("report.pdf"), null, null), false},
+		{"inline content with filename", config(null, cty.StringVal("hello"), cty.StringVal("notes.txt")), false},
+		{"inline content without filename", config(null, cty.StringVal("hello"), null), true},
+		{"inline content with unknown filename", config(null, cty.StringVal("hello"), cty.UnknownVal(cty.String)), false},
+		{"unknown source", config(cty.UnknownVal(cty.String), null, null), false},
+		{"no config", cty.NullVal(cty.DynamicPseudoType), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAttachmentFilename(tc.raw)
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			var pathErr cty.PathError
+			if !errors.As(err, &pathErr) {
+				t.Fatalf("err = %v, want a cty.PathError", err)
+			}
+			if got := formatPath(pathErr.Path); got != "filename" {
+				t.Errorf("path = %s, want filename", got)
+			}
+		})
+	}
+}
+
+func TestResourceTicketAttachmentDiffHash(t *testing.T) {
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"ticketid": "t1", "filename": "notes.txt", "content": "hello"})
+	diff, err := resourceTicketAttachment().Diff(context.Background(), nil, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got := diff.Attributes["contentsha256"].New; got != want {
+		t.Errorf("contentsha256 = %s, want %s", got, want)
+	}
+}
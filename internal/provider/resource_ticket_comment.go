@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// resourceTicketComment defines the cloudportal_ticket_comment resource which
+// posts a comment on a ticket. The id has the form <ticket-id>/<comment-id>.
+func resourceTicketComment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTicketCommentCreate,
+		ReadContext:   resourceTicketCommentRead,
+		UpdateContext: resourceTicketCommentUpdate,
+		DeleteContext: resourceTicketCommentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Unique identifier of the ticket the comment is posted on",
+			},
+			"content": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Comment content",
+			},
+			"commentid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Comment ID",
+			},
+			"createdat": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Comment creation timestamp",
+			},
+			"modifiedat": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Comment modification timestamp",
+			},
+			"author": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     userschema(),
+			},
+			"iseditable": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the comment is editable",
+			},
+		},
+	}
+}
+
+// resourceTicketCommentCreate posts the comment on the ticket
+func resourceTicketCommentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+
+	comment, err := client.CreateComment(ctx, ticketID, d.Get("content").(string))
+	if err != nil {
+		return diag.Errorf("error posting comment on ticket %s: %s", ticketID, err)
+	}
+	if comment.ID == "" {
+		return diag.Errorf("error posting comment on ticket %s: API returned no comment id", ticketID)
+	}
+
+	logger.Info("Posted comment " + comment.ID + " on ticket " + ticketID)
+	d.SetId(ticketID + "/" + comment.ID)
+
+	return resourceTicketCommentRead(ctx, d, meta)
+}
+
+// resourceTicketCommentRead reads the comment from the comments of its ticket
+func resourceTicketCommentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, commentID, err := parseTicketChildID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		if cloudportal.IsNotFound(err) {
+			logger.Info("Ticket " + ticketID + " not found, removing comment from state")
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	var comment *cloudportal.Comment
+	for i := range ticket.Comments {
+		if ticket.Comments[i].ID == commentID {
+			comment = &ticket.Comments[i]
+		}
+	}
+	if comment == nil {
+		logger.Info("Comment " + d.Id() + " not found, removing from state")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("ticketid", ticketID)
+	d.Set("commentid", comment.ID)
+	d.Set("content", comment.Content)
+	d.Set("createdat", comment.Createdat)
+	d.Set("modifiedat", comment.Modifiedat)
+	d.Set("author", flattenUsers([]cloudportal.User{comment.Author}))
+	d.Set("iseditable", comment.Iseditable)
+
+	return nil
+}
+
+// resourceTicketCommentUpdate edits the content of the comment, which the
+// portal only allows while the comment is editable
+func resourceTicketCommentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, commentID, err := parseTicketChildID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.Get("iseditable").(bool) {
+		return diag.Errorf("comment %s on ticket %s is no longer editable", commentID, ticketID)
+	}
+
+	if _, err := client.UpdateComment(ctx, ticketID, commentID, d.Get("content").(string)); err != nil {
+		return diag.Errorf("error updating comment %s on ticket %s: %s", commentID, ticketID, err)
+	}
+
+	return resourceTicketCommentRead(ctx, d, meta)
+}
+
+// resourceTicketCommentDelete removes the comment from the ticket
+func resourceTicketCommentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, commentID, err := parseTicketChildID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := client.DeleteComment(ctx, ticketID, commentID); err != nil && !cloudportal.IsNotFound(err) {
+		return diag.Errorf("error deleting comment %s on ticket %s: %s", commentID, ticketID, err)
+	}
+
+	d.SetId("")
+	return nil
+}
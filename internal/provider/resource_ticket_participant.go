@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// participantRoles are the roles a participant can have on a ticket
+var participantRoles = []string{"approver", "watcher", "requester"}
+
+// resourceTicketParticipant defines the cloudportal_ticket_participant
+// resource which adds a user or distribution list to a ticket. The id has the
+// form <ticket-id>/<role>/<email or user principal name>.
+func resourceTicketParticipant() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTicketParticipantCreate,
+		ReadContext:   resourceTicketParticipantRead,
+		DeleteContext: resourceTicketParticipantDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"ticketid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Unique identifier of the ticket",
+			},
+			"email": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"email", "userprincipalname"},
+				Description:  "Email address of the user or distribution list",
+			},
+			"userprincipalname": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"email", "userprincipalname"},
+				Description:  "User principal name of the user",
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(participantRoles, true),
+				Description:  "Role of the participant, one of approver, watcher or requester",
+			},
+			"userinfo": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Details of the user as known to the portal",
+				Elem:        userschema(),
+			},
+		},
+	}
+}
+
+// resourceTicketParticipantCreate adds the participant to the ticket
+func resourceTicketParticipantCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+	ticketID := d.Get("ticketid").(string)
+	role := d.Get("role").(string)
+
+	participant := cloudportal.Participant{
+		UserInfo: cloudportal.User{
+			Email:             d.Get("email").(string),
+			UserPrincipalName: d.Get("userprincipalname").(string),
+		},
+		Role: role,
+	}
+
+	user := participant.UserInfo.Email
+	if user == "" {
+		user = participant.UserInfo.UserPrincipalName
+	}
+
+	if err := client.AddParticipant(ctx, ticketID, participant); err != nil {
+		return diag.Errorf("error adding %s as %s to ticket %s: %s", user, role, ticketID, err)
+	}
+
+	logger.Info("Added " + user + " as " + role + " to ticket " + ticketID)
+	d.SetId(ticketID + "/" + role + "/" + user)
+
+	return resourceTicketParticipantRead(ctx, d, meta)
+}
+
+// resourceTicketParticipantRead looks the participant up in the ticket
+func resourceTicketParticipantRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, role, user, err := parseParticipantID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ticket, err := client.GetTicket(ctx, ticketID)
+	if err != nil {
+		if cloudportal.IsNotFound(err) {
+			logger.Info("Ticket " + ticketID + " not found, removing participant from state")
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading ticket %s: %s", ticketID, err)
+	}
+
+	var participant *cloudportal.Participant
+	for i, p := range ticket.Participants {
+		if !strings.EqualFold(p.Role, role) {
+			continue
+		}
+		if strings.EqualFold(p.UserInfo.Email, user) || strings.EqualFold(p.UserInfo.UserPrincipalName, user) {
+			participant = &ticket.Participants[i]
+		}
+	}
+	if participant == nil {
+		logger.Info("Participant " + d.Id() + " not found, removing from state")
+		d.SetId("")
+		return nil
+	}
+
+	// Keep the identifier the participant was added with, on import prefer the email
+	if d.Get("userprincipalname").(string) == "" && (d.Get("email").(string) != "" || strings.EqualFold(participant.UserInfo.Email, user)) {
+		d.Set("email", user)
+	} else {
+		d.Set("userprincipalname", user)
+	}
+
+	d.Set("ticketid", ticketID)
+	d.Set("role", role)
+	d.Set("userinfo", flattenUsers([]cloudportal.User{participant.UserInfo}))
+
+	return nil
+}
+
+// resourceTicketParticipantDelete removes the participant from the ticket
+func resourceTicketParticipantDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*cloudportal.CloudportalAPIClient)
+
+	ticketID, role, user, err := parseParticipantID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := client.RemoveParticipant(ctx, ticketID, user, role); err != nil && !cloudportal.IsNotFound(err) {
+		return diag.Errorf("error removing %s as %s from ticket %s: %s", user, role, ticketID, err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// parseParticipantID splits a participant id into ticket id, role and user
+func parseParticipantID(id string) (string, string, string, error) {
+	ticketID, rest, err := parseTicketChildID(id)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("unexpected format of id %q, expected <ticket-id>/<role>/<user>", id)
+	}
+	return ticketID, parts[0], parts[1], nil
+}
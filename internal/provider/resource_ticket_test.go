@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+func TestFlattenConfiguredCatalogItems(t *testing.T) {
+	items := []cloudportal.CatalogItem{{
+		Name: "vm",
+		CatalogFields: []cloudportal.CatalogField{
+			{Key: "size", Value: "S"},
+			{Key: "region", Value: "westeurope"},
+			{Key: "name", Value: "web01"},
+		},
+	}}
+	configured := func(keys ...string) []interface{} {
+		var fields []interface{}
+		for _, key := range keys {
+			fields = append(fields, map[string]interface{}{"key": key, "value": ""})
+		}
+		return []interface{}{map[string]interface{}{"name": "vm", "catalogfields": fields}}
+	}
+
+	cases := []struct {
+		name       string
+		configured []interface{}
+		want       []string
+	}{
+		{"import keeps all fields", nil, []string{"size", "region", "name"}},
+		{"configured fields in config order", configured("name", "size"), []string{"name", "size"}},
+		{"unknown keys are dropped", configured("size", "bogus"), []string{"size"}},
+		{"no configured fields", configured(), nil},
+		{"other catalog item", []interface{}{map[string]interface{}{"name": "db", "catalogfields": []interface{}{}}}, []string{"size", "region", "name"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := flattenConfiguredCatalogItems(items, tc.configured)
+			m := result[0].(map[string]interface{})
+
+			var keys []string
+			for _, f := range m["catalogfields"].([]interface{}) {
+				keys = append(keys, f.(map[string]interface{})["key"].(string))
+			}
+			if !reflect.DeepEqual(keys, tc.want) {
+				t.Errorf("catalogfields = %v, want %v", keys, tc.want)
+			}
+			if n := len(m["catalogfielddefinitions"].([]interface{})); n != 3 {
+				t.Errorf("catalogfielddefinitions has %d fields, want 3", n)
+			}
+		})
+	}
+}
+
+func TestResourceTicketReadKeepsConfiguredCatalogFields(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ticketResourceSchema(), map[string]interface{}{
+		"title": "t",
+		"catalogitems": []interface{}{map[string]interface{}{
+			"name":          "vm",
+			"catalogfields": []interface{}{map[string]interface{}{"key": "size", "value": "S"}},
+		}},
+	})
+	ticket := &cloudportal.Ticket{ID: "1", CatalogItems: []cloudportal.CatalogItem{{
+		Name:          "vm",
+		CatalogFields: []cloudportal.CatalogField{{Key: "region", Value: "westeurope"}, {Key: "size", Value: "M"}},
+	}}}
+
+	configured := d.Get("catalogitems").([]interface{})
+	setTicketData(d, ticket)
+	if err := d.Set("catalogitems", flattenConfiguredCatalogItems(ticket.CatalogItems, configured)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := d.Get("catalogitems.0.catalogfields.#"); n != 1 {
+		t.Errorf("catalogfields.# = %v, want 1", n)
+	}
+	if v := d.Get("catalogitems.0.catalogfields.0.value"); v != "M" {
+		t.Errorf("catalogfields.0.value = %v, want M", v)
+	}
+	if n := d.Get("catalogitems.0.catalogfielddefinitions.#"); n != 2 {
+		t.Errorf("catalogfielddefinitions.# = %v, want 2", n)
+	}
+}
+
+func TestResourceTicketImportThenPlan(t *testing.T) {
+	item := cloudportal.CatalogItem{
+		Name:               "vm",
+		CatalogItemVersion: 2,
+		CatalogFields: []cloudportal.CatalogField{
+			{Key: "region", Value: "westeurope"},
+			{Key: "size", Value: "M"},
+			{Key: "name", Value: "web01"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/ticket/ticket-1", jsonHandler(cloudportal.Ticket{
+		ID:           "ticket-1",
+		Title:        "web server",
+		Type:         "request",
+		Status:       "Completed",
+		CatalogItems: []cloudportal.CatalogItem{item},
+	}))
+	definition := item
+	definition.CatalogFields = append([]cloudportal.CatalogField{{Key: "disk"}}, item.CatalogFields...)
+	mux.Handle("/catalogitem/vm", jsonHandler(definition))
+	client := newTestClient(t, mux)
+
+	r := resourceTicket()
+	d := r.TestResourceData()
+	d.SetId("ticket-1")
+	imported, err := resourceTicketImport(context.Background(), d, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := imported[0].State()
+
+	field := func(key, value string) map[string]interface{} {
+		return map[string]interface{}{"key": key, "value": value}
+	}
+	config := func(fields ...interface{}) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"title": "web server",
+			"catalogitems": []interface{}{map[string]interface{}{
+				"name":          "vm",
+				"catalogfields": fields,
+			}},
+		})
+	}
+
+	cases := []struct {
+		name     string
+		config   *terraform.ResourceConfig
+		wantDiff bool
+	}{
+		{"configured subset in another order", config(field("size", "M"), field("region", "westeurope")), false},
+		{"changed value", config(field("size", "L")), true},
+		{"field that is not tracked", config(field("size", "M"), field("disk", "128")), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff, err := r.Diff(context.Background(), state, tc.config, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := diff != nil && !diff.Empty(); got != tc.wantDiff {
+				t.Errorf("diff = %v, want diff %t", diff, tc.wantDiff)
+			}
+		})
+	}
+}
+
+func TestResourceTicketClarityCodeDiff(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/claritycode/CC-1", jsonHandler(cloudportal.ClarityCode{Code: "CC-1"}))
+	client := newTestClient(t, mux)
+
+	config := func(code string) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"title":       "t",
+			"claritycode": []interface{}{map[string]interface{}{"code": code}},
+		})
+	}
+
+	if _, err := resourceTicket().Diff(context.Background(), nil, config("CC-1"), client); err != nil {
+		t.Fatalf("unexpected error for an existing clarity code: %s", err)
+	}
+
+	_, err := resourceTicket().Diff(context.Background(), nil, config("CC-404"), client)
+	var pathErr cty.PathError
+	if !errors.As(err, &pathErr) {
+		t.Fatalf("err = %v, want a cty.PathError", err)
+	}
+	if got := formatPath(pathErr.Path); got != "claritycode.0.code" {
+		t.Errorf("path = %s, want claritycode.0.code", got)
+	}
+}
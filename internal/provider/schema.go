@@ -6,14 +6,18 @@ import "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 func TicketSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"id": {
-			Type:        schema.TypeString,
-			Required:    true,
-			Description: "Unique identifier for the ticket",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ExactlyOneOf: []string{"id", "ticketno"},
+			Description:  "Unique identifier for the ticket",
 		},
 		"ticketno": {
-			Type:        schema.TypeInt,
-			Optional:    true,
-			Description: "Ticket number",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Computed:     true,
+			ExactlyOneOf: []string{"id", "ticketno"},
+			Description:  "Ticket number",
 		},
 		"title": {
 			Type:        schema.TypeString,
@@ -100,16 +104,16 @@ func TicketSchema() map[string]*schema.Schema {
 			Elem:        actionschema(),
 		},
 		"editableproperties": {
-			Type:        schema.TypeString,
+			Type:        schema.TypeList,
 			Optional:    true,
 			Description: "List of editable properties of the ticket",
-			Elem:        schema.TypeString,
+			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
 		"mandatoryproperties": {
-			Type:        schema.TypeString,
+			Type:        schema.TypeList,
 			Optional:    true,
 			Description: "List of mandatory properties for the ticket",
-			Elem:        schema.TypeString,
+			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
 		"etag": {
 			Type:        schema.TypeString,
@@ -140,6 +144,167 @@ func TicketSchema() map[string]*schema.Schema {
 	}
 }
 
+// ticketResourceSchema adapts TicketSchema for the cloudportal_ticket resource.
+// Only the properties used to raise a ticket can be configured, everything the
+// portal assigns is Computed.
+func ticketResourceSchema() map[string]*schema.Schema {
+	s := TicketSchema()
+
+	// The id is assigned by the portal and managed by Terraform itself
+	delete(s, "id")
+
+	s["title"].Optional = false
+	s["title"].Required = true
+
+	for k, v := range s {
+		if !isTicketInputField(k) {
+			setComputed(v)
+		}
+	}
+
+	// The portal fills these in from the catalog when they are left out
+	for _, k := range []string{"type", "serviceprovider", "cloudplatform"} {
+		s[k].Computed = true
+	}
+
+	s["claritycode"].MaxItems = 1
+	s["claritycode"].Elem = computedExcept(claritycodeschema(), "code")
+
+	catalogitem := computedExcept(catalogitemschema(), "name", "catalogitemversion", "catalogfields")
+	catalogitem.Schema["catalogitemversion"].Required = false
+	catalogitem.Schema["catalogitemversion"].Optional = true
+	catalogitem.Schema["catalogitemversion"].Computed = true
+	catalogitem.Schema["catalogfields"].Required = false
+	catalogitem.Schema["catalogfields"].Optional = true
+	// Computed so resourceTicketCatalogFieldsDiff can drop the diff of fields
+	// that are tracked but not configured, e.g. after an import
+	catalogitem.Schema["catalogfields"].Computed = true
+	catalogitem.Schema["catalogfields"].Elem = computedExcept(catalogfieldschema(), "key", "value")
+	catalogitem.Schema["catalogfields"].Description = "Catalog fields set on the catalog item, only the configured fields are tracked"
+
+	// The complete field definitions are kept apart from the configured fields,
+	// so fields the portal adds do not show up as a diff
+	catalogitem.Schema["catalogfielddefinitions"] = &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "All catalog fields the portal returns for the catalog item",
+		Elem:        computedExcept(catalogfieldschema()),
+	}
+	s["catalogitems"].Elem = catalogitem
+
+	return s
+}
+
+// ticketInputFields are the ticket properties that can be set by the user
+var ticketInputFields = []string{
+	"title",
+	"description",
+	"type",
+	"serviceprovider",
+	"cloudplatform",
+	"claritycode",
+	"catalogitems",
+}
+
+func isTicketInputField(name string) bool {
+	for _, field := range ticketInputFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setComputed turns an attribute into a read-only, server populated attribute
+func setComputed(s *schema.Schema) {
+	s.Required = false
+	s.Optional = false
+	s.Computed = true
+	s.MaxItems = 0
+	s.MinItems = 0
+	s.ExactlyOneOf = nil
+}
+
+// computedExcept marks every attribute of the nested resource as Computed,
+// except the ones listed in keep
+func computedExcept(r *schema.Resource, keep ...string) *schema.Resource {
+	for k, v := range r.Schema {
+		kept := false
+		for _, name := range keep {
+			if name == k {
+				kept = true
+			}
+		}
+		if !kept {
+			setComputed(v)
+		}
+	}
+	return r
+}
+
+// Define the schema for the trimmed ticket returned by list data sources
+func ticketsummaryschema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Unique identifier for the ticket",
+			},
+			"ticketno": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Ticket number",
+			},
+			"title": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Ticket title",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Current status of the ticket",
+			},
+			"substatus": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Sub-status of the ticket",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Ticket type",
+			},
+			"serviceprovider": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Service provider name",
+			},
+			"cloudplatform": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Cloud platform for the ticket",
+			},
+			"createdat": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Timestamp when the ticket was created",
+			},
+			"createdbyemail": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Email address of the user who created the ticket",
+			},
+			"claritycode": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Clarity code of the ticket",
+			},
+		},
+	}
+}
+
 // Define the schema for the user object
 func userschema() *schema.Resource {
 	return &schema.Resource{
@@ -168,7 +333,7 @@ func userschema() *schema.Resource {
 				Type:        schema.TypeList,
 				Required:    true,
 				Description: "Roles of the user",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 		},
 	}
@@ -197,7 +362,7 @@ func claritycodeschema() *schema.Resource {
 				Type:        schema.TypeList,
 				Optional:    true,
 				Description: "List of emails related to the clarity code",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"tower": {
 				Type:        schema.TypeString,
@@ -332,6 +497,32 @@ func billingitemschema() *schema.Resource {
 	}
 }
 
+// Define the schema for a billing item together with the ticket it belongs to
+func ticketbillingitemschema() *schema.Resource {
+	r := billingitemschema()
+	r.Schema["ticketid"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Unique identifier of the ticket",
+	}
+	r.Schema["ticketno"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: "Ticket number",
+	}
+	r.Schema["claritycode"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Clarity code of the ticket",
+	}
+	r.Schema["totalcost"] = &schema.Schema{
+		Type:        schema.TypeFloat,
+		Computed:    true,
+		Description: "Total cost of the billing item over all invoice periods",
+	}
+	return r
+}
+
 func historyitemschema() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
@@ -367,13 +558,13 @@ func changeschema() *schema.Resource {
 				Type:        schema.TypeMap,
 				Optional:    true,
 				Description: "Old value of the changed property",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"newvalue": {
 				Type:        schema.TypeMap,
 				Optional:    true,
 				Description: "New value of the changed property",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 		},
 	}
@@ -391,7 +582,7 @@ func actionschema() *schema.Resource {
 				Type:        schema.TypeList,
 				Required:    true,
 				Description: "List of required properties for the action",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"type": {
 				Type:        schema.TypeString,
@@ -467,7 +658,7 @@ func catalogitemschema() *schema.Resource {
 				Type:        schema.TypeList,
 				Required:    true,
 				Description: "List of ticket types for this catalog item",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"active": {
 				Type:        schema.TypeBool,
@@ -509,7 +700,7 @@ func catalogitemschema() *schema.Resource {
 				Type:        schema.TypeMap,
 				Required:    true,
 				Description: "Variables associated with the catalog item",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"resourcecontractname": {
 				Type:        schema.TypeString,
@@ -558,7 +749,7 @@ func catalogfieldschema() *schema.Resource {
 				Type:        schema.TypeList,
 				Optional:    true,
 				Description: "List of possible look-up values for the catalog field",
-				Elem:        schema.TypeString,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"hintvalue": {
 				Type:        schema.TypeString,
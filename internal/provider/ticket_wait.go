@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+// defaultTicketFailureStatuses are the statuses treated as terminal errors when
+// wait_failure_statuses is not configured
+var defaultTicketFailureStatuses = []string{"Failed", "Rejected", "Cancelled"}
+
+// ticketWaitSchema defines the arguments used to wait for a ticket to reach a status
+func ticketWaitSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"wait_for_status": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Wait until the ticket reaches this status (e.g. Completed)",
+		},
+		"wait_for_substatus": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Wait until the ticket reaches this sub-status",
+		},
+		"wait_failure_statuses": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Statuses that stop the wait with an error, defaults to Failed, Rejected and Cancelled",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+// addTicketWaitSchema adds the wait arguments to a ticket schema
+func addTicketWaitSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	for k, v := range ticketWaitSchema() {
+		s[k] = v
+	}
+	return s
+}
+
+// waitForTicketStatus polls the ticket until it reaches the configured
+// wait_for_status and wait_for_substatus. It returns nil without polling when
+// no wait is configured.
+func waitForTicketStatus(ctx context.Context, client *cloudportal.CloudportalAPIClient, d *schema.ResourceData, id string, timeout time.Duration) (*cloudportal.Ticket, error) {
+	status := d.Get("wait_for_status").(string)
+	substatus := d.Get("wait_for_substatus").(string)
+	if status == "" && substatus == "" {
+		return nil, nil
+	}
+
+	failureStatuses := defaultTicketFailureStatuses
+	if v, ok := d.GetOk("wait_failure_statuses"); ok {
+		failureStatuses = nil
+		for _, s := range v.([]interface{}) {
+			failureStatuses = append(failureStatuses, s.(string))
+		}
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{"waiting"},
+		Target:     []string{"done"},
+		Timeout:    timeout,
+		MinTimeout: 5 * time.Second,
+		Refresh: func() (interface{}, string, error) {
+			ticket, err := client.GetTicket(ctx, id)
+			if err != nil {
+				return nil, "", err
+			}
+			logger.Debug("Ticket " + id + " status : " + ticket.Status + " / " + ticket.SubStatus)
+
+			state, err := ticketWaitState(ticket, status, substatus, failureStatuses)
+			return ticket, state, err
+		},
+	}
+
+	result, err := stateConf.WaitForStateContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for ticket %s: %s", id, err)
+	}
+
+	return result.(*cloudportal.Ticket), nil
+}
+
+// ticketWaitState maps the ticket to the state of the wait. The target is
+// checked first, so waiting for a status such as Cancelled succeeds even
+// though it is a failure status by default.
+func ticketWaitState(ticket *cloudportal.Ticket, status, substatus string, failureStatuses []string) (string, error) {
+	if (status == "" || strings.EqualFold(ticket.Status, status)) &&
+		(substatus == "" || strings.EqualFold(ticket.SubStatus, substatus)) {
+		return "done", nil
+	}
+
+	for _, failed := range failureStatuses {
+		if strings.EqualFold(ticket.Status, failed) {
+			return "", fmt.Errorf("ticket %s reached status %q (%s) while waiting for %q", ticket.ID, ticket.Status, ticket.SubStatus, status)
+		}
+	}
+
+	return "waiting", nil
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/pkg/cloudportal"
+)
+
+func TestTicketWaitState(t *testing.T) {
+	cases := []struct {
+		name      string
+		waitFor   string
+		waitSub   string
+		status    string
+		substatus string
+		wantState string
+		wantErr   bool
+	}{
+		{name: "target reached", status: "Completed", waitFor: "completed", wantState: "done"},
+		{name: "still pending", status: "InProgress", waitFor: "Completed", wantState: "waiting"},
+		{name: "failure status", status: "Failed", waitFor: "Completed", wantErr: true},
+		{name: "waiting for a failure status", status: "Cancelled", waitFor: "Cancelled", wantState: "done"},
+		{name: "waiting for a rejected ticket", status: "Rejected", waitFor: "Rejected", wantState: "done"},
+		{name: "substatus pending", status: "Completed", substatus: "Provisioning", waitFor: "Completed", waitSub: "Done", wantState: "waiting"},
+		{name: "substatus reached", status: "Completed", substatus: "Done", waitSub: "Done", wantState: "done"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ticket := &cloudportal.Ticket{ID: "1", Status: tc.status, SubStatus: tc.substatus}
+			state, err := ticketWaitState(ticket, tc.waitFor, tc.waitSub, defaultTicketFailureStatuses)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tc.wantErr)
+			}
+			if state != tc.wantState {
+				t.Errorf("state = %q, want %q", state, tc.wantState)
+			}
+		})
+	}
+}
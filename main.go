@@ -1,6 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/costexport"
 	"github.com/terraform-provider-cloudportal/cloudportal/internal/provider"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
@@ -9,6 +13,15 @@ import (
 // main function is the entry point of the provider plugin
 func main() {
 
+	// The binary doubles as a cost export tool for the finance team
+	if len(os.Args) > 1 && os.Args[1] == costexport.Command {
+		if err := costexport.Run(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Use the plugin library to start the provider
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: provider.Provider, // Provider is the function you defined in provider/provider.go
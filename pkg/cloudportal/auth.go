@@ -0,0 +1,157 @@
+package cloudportal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// Supported values of CredentialConfig.AuthMethod
+const (
+	AuthMethodClientSecret      = "client_secret"
+	AuthMethodClientCertificate = "client_certificate"
+	AuthMethodManagedIdentity   = "managed_identity"
+	AuthMethodWorkloadIdentity  = "workload_identity"
+	AuthMethodAzureCLI          = "azure_cli"
+	AuthMethodDefault           = "default"
+)
+
+// AuthMethods lists all supported auth methods
+var AuthMethods = []string{
+	AuthMethodClientSecret,
+	AuthMethodClientCertificate,
+	AuthMethodManagedIdentity,
+	AuthMethodWorkloadIdentity,
+	AuthMethodAzureCLI,
+	AuthMethodDefault,
+}
+
+// Well known values of the authority_host provider setting, any other value
+// must be the URL of a custom authority
+var authorityHosts = map[string]cloud.Configuration{
+	"public":       cloud.AzurePublic,
+	"usgovernment": cloud.AzureGovernment,
+	"china":        cloud.AzureChina,
+}
+
+// CredentialConfig holds the settings used to obtain tokens for the portal API
+type CredentialConfig struct {
+	AuthMethod                string
+	TenantID                  string
+	ClientID                  string
+	ClientSecret              string
+	ClientCertificatePath     string
+	ClientCertificatePassword string
+	OIDCTokenFilePath         string
+	AuthorityHost             string
+	Transport                 policy.Transporter // Sends token requests, e.g. an http.Client using NewTransport
+}
+
+// ErrNoTokenScope is returned by TokenScopes when neither scopes, an
+// application ID URI nor a tenant ID are configured
+var ErrNoTokenScope = errors.New("no scope to request portal API tokens for")
+
+// TokenScopes returns the scopes requested for portal API tokens. Explicit
+// scopes win over the application ID URI, without either the tenant ID is
+// used as the application ID URI for backwards compatibility.
+func TokenScopes(tenantID, applicationIDURI string, scopes []string) ([]string, error) {
+	if len(scopes) > 0 {
+		return scopes, nil
+	}
+	if applicationIDURI == "" {
+		applicationIDURI = tenantID
+	}
+	if applicationIDURI == "" {
+		return nil, ErrNoTokenScope
+	}
+	return []string{strings.TrimSuffix(applicationIDURI, "/") + "/.default"}, nil
+}
+
+// cloudConfiguration resolves the authority_host setting. An empty value
+// leaves the choice to azidentity, which honours AZURE_AUTHORITY_HOST.
+func cloudConfiguration(authorityHost string) (cloud.Configuration, error) {
+	if authorityHost == "" {
+		return cloud.Configuration{}, nil
+	}
+	if c, ok := authorityHosts[strings.ToLower(authorityHost)]; ok {
+		return c, nil
+	}
+
+	u, err := url.Parse(authorityHost)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return cloud.Configuration{}, fmt.Errorf("authority_host must be public, usgovernment, china or an https URL, got %q", authorityHost)
+	}
+	return cloud.Configuration{ActiveDirectoryAuthorityHost: authorityHost}, nil
+}
+
+// NewCredential builds the azidentity credential for the configured auth method
+func NewCredential(config CredentialConfig) (azcore.TokenCredential, error) {
+	cloudConfig, err := cloudConfiguration(config.AuthorityHost)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := policy.ClientOptions{Cloud: cloudConfig, Transport: config.Transport}
+
+	switch config.AuthMethod {
+	case AuthMethodClientSecret, "":
+		if config.TenantID == "" || config.ClientID == "" || config.ClientSecret == "" {
+			return nil, fmt.Errorf("tenantID, clientID and clientSecret must be provided for auth_method %q", AuthMethodClientSecret)
+		}
+		return azidentity.NewClientSecretCredential(config.TenantID, config.ClientID, config.ClientSecret, &azidentity.ClientSecretCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+
+	case AuthMethodClientCertificate:
+		if config.TenantID == "" || config.ClientID == "" || config.ClientCertificatePath == "" {
+			return nil, fmt.Errorf("tenantID, clientID and client_certificate_path must be provided for auth_method %q", AuthMethodClientCertificate)
+		}
+		data, err := os.ReadFile(config.ClientCertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client certificate: %s", err)
+		}
+		certs, key, err := azidentity.ParseCertificates(data, []byte(config.ClientCertificatePassword))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing client certificate: %s", err)
+		}
+		return azidentity.NewClientCertificateCredential(config.TenantID, config.ClientID, certs, key, &azidentity.ClientCertificateCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+
+	case AuthMethodManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		// A client id selects a user-assigned identity, otherwise the system-assigned one is used
+		if config.ClientID != "" {
+			options.ID = azidentity.ClientID(config.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+
+	case AuthMethodWorkloadIdentity:
+		// Empty settings fall back to AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      config.TenantID,
+			ClientID:      config.ClientID,
+			TokenFilePath: config.OIDCTokenFilePath,
+		})
+
+	case AuthMethodAzureCLI:
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: config.TenantID,
+		})
+
+	case AuthMethodDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      config.TenantID,
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported auth_method %q", config.AuthMethod)
+}
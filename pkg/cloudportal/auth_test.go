@@ -0,0 +1,34 @@
+package cloudportal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTokenScopes(t *testing.T) {
+	cases := []struct {
+		name             string
+		tenantID         string
+		applicationIDURI string
+		scopes           []string
+		want             []string
+		wantErr          error
+	}{
+		{"explicit scopes", "tenant", "api://portal", []string{"api://other/read"}, []string{"api://other/read"}, nil},
+		{"application id uri", "tenant", "api://portal/", nil, []string{"api://portal/.default"}, nil},
+		{"tenant id fallback", "tenant", "", nil, []string{"tenant/.default"}, nil},
+		{"nothing configured", "", "", nil, nil, ErrNoTokenScope},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := TokenScopes(tc.tenantID, tc.applicationIDURI, tc.scopes)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("scopes = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
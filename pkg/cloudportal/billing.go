@@ -0,0 +1,150 @@
+package cloudportal
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// BillingSummary aggregates the billing items of a set of tickets
+type BillingSummary struct {
+	Items              []TicketBillingItem // Billing items with their ticket, sorted by ticket number and subscription
+	SubscriptionTotals map[string]float64  // Total cost per subscription name, names differing in case only are added up under the first spelling seen
+	ClarityCodeTotals  map[string]float64  // Total cost per clarity code
+	PeriodTotals       map[string]float64  // Total cost per invoice period
+	TotalCost          float64             // Total cost of all billing items
+}
+
+// TicketBillingItem is a billing item together with the ticket it belongs to
+type TicketBillingItem struct {
+	TicketID       string
+	TicketNo       int
+	ClarityCode    string
+	BillingItem    BillingItem
+	InvoicePeriods []InvoicePeriod // Invoice periods sorted chronologically
+	TotalCost      float64
+}
+
+// SummarizeBilling aggregates the billing items of the tickets. When
+// subscriptions is not empty only billing items of those subscriptions are
+// included.
+func SummarizeBilling(tickets []Ticket, subscriptions []string) *BillingSummary {
+	summary := &BillingSummary{
+		SubscriptionTotals: make(map[string]float64),
+		ClarityCodeTotals:  make(map[string]float64),
+		PeriodTotals:       make(map[string]float64),
+	}
+	// The portal does not keep the case of subscription names consistent
+	subscriptionNames := make(map[string]string)
+
+	for _, ticket := range tickets {
+		for _, item := range ticket.BillingItems {
+			if len(subscriptions) > 0 && !containsFold(subscriptions, item.SubscriptionName) {
+				continue
+			}
+
+			entry := TicketBillingItem{
+				TicketID:       ticket.ID,
+				TicketNo:       ticket.TicketNo,
+				ClarityCode:    ticket.ClarityCode.Code,
+				BillingItem:    item,
+				InvoicePeriods: SortedInvoicePeriods(item.InvoicePeriods),
+			}
+			for _, period := range entry.InvoicePeriods {
+				entry.TotalCost += period.ActualCost
+				summary.PeriodTotals[period.InvoicePeriod] += period.ActualCost
+			}
+
+			key := strings.ToLower(item.SubscriptionName)
+			if _, ok := subscriptionNames[key]; !ok {
+				subscriptionNames[key] = item.SubscriptionName
+			}
+			summary.SubscriptionTotals[subscriptionNames[key]] += entry.TotalCost
+			summary.ClarityCodeTotals[ticket.ClarityCode.Code] += entry.TotalCost
+			summary.TotalCost += entry.TotalCost
+			summary.Items = append(summary.Items, entry)
+		}
+	}
+
+	sort.SliceStable(summary.Items, func(i, j int) bool {
+		if summary.Items[i].TicketNo != summary.Items[j].TicketNo {
+			return summary.Items[i].TicketNo < summary.Items[j].TicketNo
+		}
+		a, b := summary.Items[i].BillingItem.SubscriptionName, summary.Items[j].BillingItem.SubscriptionName
+		if !strings.EqualFold(a, b) {
+			return strings.ToLower(a) < strings.ToLower(b)
+		}
+		return a < b
+	})
+
+	return summary
+}
+
+// billingDateLayouts are the date formats seen in invoice period start dates
+// and names
+var billingDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"1/2/2006 3:04:05 PM",
+	"1/2/2006",
+	"2006-01",
+	"Jan 2006",
+	"January 2006",
+}
+
+// ParseBillingDate parses a billing date in one of the formats used by the
+// portal, it reports false when none of them matches
+func ParseBillingDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range billingDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// InvoicePeriodStart returns the start of the invoice period, taken from its
+// start date or else from its name
+func InvoicePeriodStart(period InvoicePeriod) (time.Time, bool) {
+	if start, ok := ParseBillingDate(period.StartDate); ok {
+		return start, true
+	}
+	return ParseBillingDate(period.InvoicePeriod)
+}
+
+// SortedInvoicePeriods returns the invoice periods sorted chronologically by
+// start date, falling back to the date in the period name. Periods without
+// either follow, sorted by name. The map key is used as the period name.
+func SortedInvoicePeriods(invoicePeriods map[string]InvoicePeriod) []InvoicePeriod {
+	result := make([]InvoicePeriod, 0, len(invoicePeriods))
+	for key, period := range invoicePeriods {
+		period.InvoicePeriod = key
+		result = append(result, period)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, okA := InvoicePeriodStart(result[i])
+		b, okB := InvoicePeriodStart(result[j])
+		if okA != okB {
+			return okA
+		}
+		if okA && !a.Equal(b) {
+			return a.Before(b)
+		}
+		return result[i].InvoicePeriod < result[j].InvoicePeriod
+	})
+
+	return result
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package cloudportal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeBilling(t *testing.T) {
+	tickets := []Ticket{
+		{ID: "a", TicketNo: 2, ClarityCode: ClarityCode{Code: "C1"}, BillingItems: []BillingItem{
+			{SubscriptionName: "s1", InvoicePeriods: map[string]InvoicePeriod{
+				"2024-02": {ActualCost: 2, StartDate: "2024-02-01"},
+				"2024-01": {ActualCost: 1, StartDate: "2024-01-01"},
+				"2023-12": {ActualCost: 4, StartDate: "2023-12-01"},
+			}},
+		}},
+		{ID: "b", TicketNo: 1, ClarityCode: ClarityCode{Code: "C2"}, BillingItems: []BillingItem{
+			{SubscriptionName: "s2", InvoicePeriods: map[string]InvoicePeriod{"2024-01": {ActualCost: 10}}},
+			{SubscriptionName: "S1", InvoicePeriods: map[string]InvoicePeriod{"2024-01": {ActualCost: 3}}},
+		}},
+	}
+
+	cases := []struct {
+		name          string
+		subscriptions []string
+		wantItems     []string
+		wantTotal     float64
+		wantSubs      map[string]float64
+		wantPeriods   map[string]float64
+		wantCodes     map[string]float64
+	}{
+		{
+			name:        "all subscriptions",
+			wantItems:   []string{"b/S1", "b/s2", "a/s1"},
+			wantTotal:   20,
+			wantSubs:    map[string]float64{"s1": 10, "s2": 10},
+			wantPeriods: map[string]float64{"2023-12": 4, "2024-01": 14, "2024-02": 2},
+			wantCodes:   map[string]float64{"C1": 7, "C2": 13},
+		},
+		{
+			name:          "subscriptions match case-insensitively",
+			subscriptions: []string{"s1"},
+			wantItems:     []string{"b/S1", "a/s1"},
+			wantTotal:     10,
+			wantSubs:      map[string]float64{"s1": 10},
+			wantPeriods:   map[string]float64{"2023-12": 4, "2024-01": 4, "2024-02": 2},
+			wantCodes:     map[string]float64{"C1": 7, "C2": 3},
+		},
+		{
+			name:          "unknown subscription",
+			subscriptions: []string{"s3"},
+			wantSubs:      map[string]float64{},
+			wantPeriods:   map[string]float64{},
+			wantCodes:     map[string]float64{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			summary := SummarizeBilling(tickets, tc.subscriptions)
+
+			var items []string
+			for _, item := range summary.Items {
+				items = append(items, item.TicketID+"/"+item.BillingItem.SubscriptionName)
+			}
+			if !reflect.DeepEqual(items, tc.wantItems) {
+				t.Errorf("items = %v, want %v", items, tc.wantItems)
+			}
+			if summary.TotalCost != tc.wantTotal {
+				t.Errorf("total = %v, want %v", summary.TotalCost, tc.wantTotal)
+			}
+			if !reflect.DeepEqual(summary.SubscriptionTotals, tc.wantSubs) {
+				t.Errorf("subscription totals = %v, want %v", summary.SubscriptionTotals, tc.wantSubs)
+			}
+			if !reflect.DeepEqual(summary.PeriodTotals, tc.wantPeriods) {
+				t.Errorf("period totals = %v, want %v", summary.PeriodTotals, tc.wantPeriods)
+			}
+			if !reflect.DeepEqual(summary.ClarityCodeTotals, tc.wantCodes) {
+				t.Errorf("clarity code totals = %v, want %v", summary.ClarityCodeTotals, tc.wantCodes)
+			}
+		})
+	}
+}
+
+func TestSortedInvoicePeriods(t *testing.T) {
+	cases := []struct {
+		name    string
+		periods map[string]InvoicePeriod
+		want    []string
+	}{
+		{
+			name: "iso start dates",
+			periods: map[string]InvoicePeriod{
+				"Feb 2024": {StartDate: "2024-02-01"},
+				"Dec 2023": {StartDate: "2023-12-01"},
+				"Jan 2024": {StartDate: "2024-01-01T00:00:00Z"},
+			},
+			want: []string{"Dec 2023", "Jan 2024", "Feb 2024"},
+		},
+		{
+			name: "us start dates",
+			periods: map[string]InvoicePeriod{
+				"p2": {StartDate: "1/5/2024"},
+				"p1": {StartDate: "12/1/2023"},
+			},
+			want: []string{"p1", "p2"},
+		},
+		{
+			name: "dates in the names",
+			periods: map[string]InvoicePeriod{
+				"2024-01":    {},
+				"Dec 2023":   {StartDate: "unknown"},
+				"March 2024": {},
+			},
+			want: []string{"Dec 2023", "2024-01", "March 2024"},
+		},
+		{
+			name: "periods without dates follow by name",
+			periods: map[string]InvoicePeriod{
+				"b":        {},
+				"Jan 2024": {StartDate: "2024-01-01"},
+				"a":        {},
+			},
+			want: []string{"Jan 2024", "a", "b"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var names []string
+			for _, period := range SortedInvoicePeriods(tc.periods) {
+				names = append(names, period.InvoicePeriod)
+			}
+			if !reflect.DeepEqual(names, tc.want) {
+				t.Errorf("periods = %v, want %v", names, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,559 @@
+// Package cloudportal is a typed client for the cloud portal API. It is used by
+// the Terraform provider and can be imported by other tools.
+package cloudportal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+)
+
+// DefaultRequestTimeout is the request timeout of clients created with
+// NewCloudportalAPIClient
+const DefaultRequestTimeout = 2 * time.Minute
+
+// CloudportalAPIClient represents a custom API client that communicates with the API
+type CloudportalAPIClient struct {
+	BaseURL   string
+	APIKey    string
+	Client    *http.Client
+	aziclient azcore.TokenCredential
+	isdebug   bool
+	scopes    []string
+	tokens    *tokenCache
+	retries   *retryTransport
+	limits    *rateLimitTransport
+}
+
+// NewCloudportalAPIClient initializes a new API client which requests tokens
+// for the given scopes, see TokenScopes. Failed requests are retried with
+// DefaultRetryConfig and time out after DefaultRequestTimeout, requests are
+// not rate limited.
+func NewCloudportalAPIClient(credential azcore.TokenCredential, apiKey, baseURL string, scopes []string, debuginfo bool) *CloudportalAPIClient {
+	// Every attempt of a retried request passes the rate limiter
+	limits := &rateLimitTransport{base: http.DefaultTransport, limiter: newRateLimiter(RateLimitConfig{})}
+	retries := &retryTransport{base: limits, config: DefaultRetryConfig}
+	tokens := newTokenCache(credential, scopes, nil)
+	tokens.timeout = DefaultRequestTimeout
+
+	return &CloudportalAPIClient{
+		BaseURL:   baseURL,
+		APIKey:    apiKey,
+		Client:    &http.Client{Transport: retries, Timeout: DefaultRequestTimeout},
+		aziclient: credential,
+		isdebug:   debuginfo,
+		scopes:    scopes,
+		tokens:    tokens,
+		retries:   retries,
+		limits:    limits,
+	}
+}
+
+// UseTokenCacheFile persists the tokens of the client between runs in a file
+// encrypted with the passphrase, shared by all identities in config
+func (c *CloudportalAPIClient) UseTokenCacheFile(path, passphrase string, config CredentialConfig) {
+	identity := []string{config.AuthMethod, config.AuthorityHost, config.TenantID, config.ClientID}
+	tokens := newTokenCache(c.aziclient, c.scopes, newTokenCacheFile(path, passphrase, identity, c.scopes))
+	tokens.timeout = c.tokens.timeout
+	c.tokens = tokens
+}
+
+// SetRequestTimeout limits the time a single call of the client may take,
+// including retries, and the time it may wait for an access token. A timeout
+// of 0 disables the limit.
+func (c *CloudportalAPIClient) SetRequestTimeout(timeout time.Duration) {
+	c.Client.Timeout = timeout
+	c.tokens.timeout = timeout
+}
+
+// SetTransport replaces the transport that sends the requests of the client,
+// e.g. one built by NewTransport. Retries and rate limiting are kept.
+func (c *CloudportalAPIClient) SetTransport(transport http.RoundTripper) {
+	c.limits.base = transport
+}
+
+// SetRetryConfig changes how failed requests of the client are retried
+func (c *CloudportalAPIClient) SetRetryConfig(config RetryConfig) {
+	c.retries.config = config
+}
+
+// SetRateLimit limits the requests of the client, shared by all its calls. It
+// must be called before the client is used.
+func (c *CloudportalAPIClient) SetRateLimit(config RateLimitConfig) {
+	c.limits.limiter = newRateLimiter(config)
+}
+
+// Debug reports whether the client was created with debug logging enabled
+func (c *CloudportalAPIClient) Debug() bool {
+	return c.isdebug
+}
+
+// APIError is returned when the portal API answers with a non-success status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("API call failed with status %d: %s: %s", e.StatusCode, e.Status, e.Body)
+	}
+	return fmt.Sprintf("API call failed with status %d: %s", e.StatusCode, e.Status)
+}
+
+// IsNotFound reports whether err is an API error with status 404
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// getToken obtains an access token for the portal API, reusing the cached
+// token until shortly before it expires
+func (c *CloudportalAPIClient) getToken(ctx context.Context) (string, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		return "", fmt.Errorf("failed to obtain a token: %s", err)
+	}
+
+	return token, nil
+}
+
+// newRequest builds an authenticated request against the portal API. The body,
+// if not nil, is encoded as JSON.
+func (c *CloudportalAPIClient) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	if body == nil {
+		return c.newRawRequest(ctx, method, path, nil, "")
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %s", err)
+	}
+	logger.Debug(string(data))
+
+	return c.newRawRequest(ctx, method, path, bytes.NewReader(data), "application/json")
+}
+
+// newRawRequest builds an authenticated request with a body of the given content type
+func (c *CloudportalAPIClient) newRawRequest(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Request, error) {
+	requestURL := fmt.Sprintf("%s/%s", c.BaseURL, path)
+	logger.Debug(method + " " + requestURL)
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, fmt.Errorf("failed to create HTTP request: %s", err)
+	}
+
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set custom headers
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
+	req.Header.Set("Accept-Language", "en-IN,en-GB;q=0.9,en;q=0.8,en-US;q=0.7")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Add("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
+}
+
+// do sends the request and decodes the JSON response into out, if given
+func (c *CloudportalAPIClient) do(req *http.Request, out interface{}) error {
+	bodyBytes, err := c.doRaw(req)
+	if err != nil {
+		return err
+	}
+
+	// Print the raw response for debugging
+	logger.Debug(string(bodyBytes))
+
+	if out == nil || len(bodyBytes) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(bodyBytes, out); err != nil {
+		logger.Error(err.Error())
+		return fmt.Errorf("failed to decode response: %s", err)
+	}
+
+	return nil
+}
+
+// doRaw sends the request and returns the decompressed response body
+func (c *CloudportalAPIClient) doRaw(req *http.Request) ([]byte, error) {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		logger.Error("Send request : " + err.Error())
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	logger.Debug(resp.Status)
+
+	// Check if the response is gzip encoded
+	var reader io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		// Create a new gzip reader to decompress the content
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			logger.Error(err.Error())
+			return nil, fmt.Errorf("failed to decompress response: %s", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	// Read the decompressed body
+	bodyBytes, err := io.ReadAll(reader)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, fmt.Errorf("failed to read response: %s", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logger.Error("Response status : " + resp.Status)
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(bodyBytes)}
+	}
+
+	return bodyBytes, nil
+}
+
+// doRequest builds and sends a request in one step
+func (c *CloudportalAPIClient) doRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+// GetTicket fetches a single ticket by its id
+func (c *CloudportalAPIClient) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	var ticket Ticket
+	if err := c.doRequest(ctx, http.MethodGet, "ticket/"+id, nil, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// TicketPage is a single page of results from the ticket list endpoint
+type TicketPage struct {
+	Items             []Ticket `json:"items"`
+	ContinuationToken string   `json:"continuationtoken"`
+}
+
+// ListTickets returns all tickets matching the filter, following the
+// continuation token until the last page
+func (c *CloudportalAPIClient) ListTickets(ctx context.Context, filter url.Values) ([]Ticket, error) {
+	query := url.Values{}
+	for k, v := range filter {
+		query[k] = v
+	}
+
+	var tickets []Ticket
+	for {
+		var page TicketPage
+		if err := c.doRequest(ctx, http.MethodGet, "ticket?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, page.Items...)
+
+		if page.ContinuationToken == "" {
+			return tickets, nil
+		}
+		query.Set("continuationtoken", page.ContinuationToken)
+	}
+}
+
+// FindTicketByNumber resolves a human ticket number to the ticket. The
+// result is the trimmed ticket of the list endpoint, use GetTicket with its id
+// for the full ticket.
+func (c *CloudportalAPIClient) FindTicketByNumber(ctx context.Context, ticketNo int) (*Ticket, error) {
+	filter := url.Values{}
+	filter.Set("ticketno", strconv.Itoa(ticketNo))
+
+	tickets, err := c.ListTickets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticket := range tickets {
+		if ticket.TicketNo == ticketNo {
+			return &ticket, nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Status: http.StatusText(http.StatusNotFound), Body: fmt.Sprintf("no ticket with number %d", ticketNo)}
+}
+
+// CreateTicket raises a new ticket with the given properties
+func (c *CloudportalAPIClient) CreateTicket(ctx context.Context, properties map[string]interface{}) (*Ticket, error) {
+	var ticket Ticket
+	if err := c.doRequest(ctx, http.MethodPost, "ticket", properties, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// UpdateTicket patches the given properties of a ticket. The etag guards
+// against overwriting changes made in the portal since the ticket was read.
+func (c *CloudportalAPIClient) UpdateTicket(ctx context.Context, id, etag string, properties map[string]interface{}) (*Ticket, error) {
+	req, err := c.newRequest(ctx, http.MethodPatch, "ticket/"+id, properties)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	var ticket Ticket
+	if err := c.do(req, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// DeleteTicket cancels a ticket
+func (c *CloudportalAPIClient) DeleteTicket(ctx context.Context, id string) error {
+	return c.doRequest(ctx, http.MethodDelete, "ticket/"+id, nil, nil)
+}
+
+// SubmitTicketAction performs a workflow action such as approve or reject on a
+// ticket and returns the ticket as it is after the action
+func (c *CloudportalAPIClient) SubmitTicketAction(ctx context.Context, id, etag, actionName string, properties map[string]string) (*Ticket, error) {
+	body := map[string]interface{}{
+		"actionname": actionName,
+		"properties": properties,
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "ticket/"+id+"/action", body)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	var ticket Ticket
+	if err := c.do(req, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// ListCatalogItems returns all items of the service catalog
+func (c *CloudportalAPIClient) ListCatalogItems(ctx context.Context) ([]CatalogItem, error) {
+	var items []CatalogItem
+	if err := c.doRequest(ctx, http.MethodGet, "catalogitem", nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetCatalogItem fetches a catalog item by name. A version of 0 returns the
+// latest version of the item.
+func (c *CloudportalAPIClient) GetCatalogItem(ctx context.Context, name string, version int) (*CatalogItem, error) {
+	path := "catalogitem/" + url.PathEscape(name)
+	if version != 0 {
+		query := url.Values{}
+		query.Set("version", strconv.Itoa(version))
+		path += "?" + query.Encode()
+	}
+
+	var item CatalogItem
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// LookupValue is a single allowed value returned by a catalog lookup function
+type LookupValue struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// LookupCatalogField invokes a server-side catalog lookup function with the
+// values of the fields it depends on
+func (c *CloudportalAPIClient) LookupCatalogField(ctx context.Context, function string, parameters map[string]string) ([]LookupValue, error) {
+	// The lookup only reads, so it is retried like a GET
+	var values []LookupValue
+	if err := c.doRequest(withIdempotent(ctx), http.MethodPost, "lookup/"+url.PathEscape(function), parameters, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// CreateComment posts a new comment on a ticket
+func (c *CloudportalAPIClient) CreateComment(ctx context.Context, ticketID, content string) (*Comment, error) {
+	body := map[string]interface{}{
+		"content": content,
+	}
+
+	var comment Comment
+	if err := c.doRequest(ctx, http.MethodPost, "ticket/"+ticketID+"/comment", body, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdateComment changes the content of an editable comment
+func (c *CloudportalAPIClient) UpdateComment(ctx context.Context, ticketID, commentID, content string) (*Comment, error) {
+	body := map[string]interface{}{
+		"content": content,
+	}
+
+	var comment Comment
+	if err := c.doRequest(ctx, http.MethodPut, "ticket/"+ticketID+"/comment/"+commentID, body, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// DeleteComment removes a comment from a ticket
+func (c *CloudportalAPIClient) DeleteComment(ctx context.Context, ticketID, commentID string) error {
+	return c.doRequest(ctx, http.MethodDelete, "ticket/"+ticketID+"/comment/"+commentID, nil, nil)
+}
+
+// UploadAttachment uploads a file to a ticket
+func (c *CloudportalAPIClient) UploadAttachment(ctx context.Context, ticketID, filename string, content []byte) (*Attachment, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipart body: %s", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, fmt.Errorf("failed to create multipart body: %s", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create multipart body: %s", err)
+	}
+
+	req, err := c.newRawRequest(ctx, http.MethodPost, "ticket/"+ticketID+"/attachment", &body, writer.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment Attachment
+	if err := c.do(req, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DownloadAttachment returns the content of a ticket attachment
+func (c *CloudportalAPIClient) DownloadAttachment(ctx context.Context, ticketID, filename string) ([]byte, error) {
+	req, err := c.newRawRequest(ctx, http.MethodGet, "ticket/"+ticketID+"/attachment/"+url.PathEscape(filename), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "*/*")
+
+	return c.doRaw(req)
+}
+
+// DeleteAttachment removes an attachment from a ticket
+func (c *CloudportalAPIClient) DeleteAttachment(ctx context.Context, ticketID, filename string) error {
+	return c.doRequest(ctx, http.MethodDelete, "ticket/"+ticketID+"/attachment/"+url.PathEscape(filename), nil, nil)
+}
+
+// AddParticipant adds a user with the given role to a ticket
+func (c *CloudportalAPIClient) AddParticipant(ctx context.Context, ticketID string, participant Participant) error {
+	return c.doRequest(ctx, http.MethodPost, "ticket/"+ticketID+"/participant", participant, nil)
+}
+
+// RemoveParticipant removes the role of a user, identified by email or user
+// principal name, from a ticket
+func (c *CloudportalAPIClient) RemoveParticipant(ctx context.Context, ticketID, user, role string) error {
+	query := url.Values{}
+	query.Set("user", user)
+	query.Set("role", role)
+	return c.doRequest(ctx, http.MethodDelete, "ticket/"+ticketID+"/participant?"+query.Encode(), nil, nil)
+}
+
+// GetClarityCode fetches a single clarity code
+func (c *CloudportalAPIClient) GetClarityCode(ctx context.Context, code string) (*ClarityCode, error) {
+	var clarityCode ClarityCode
+	if err := c.doRequest(ctx, http.MethodGet, "claritycode/"+url.PathEscape(code), nil, &clarityCode); err != nil {
+		return nil, err
+	}
+	return &clarityCode, nil
+}
+
+// ListClarityCodes returns all clarity codes
+func (c *CloudportalAPIClient) ListClarityCodes(ctx context.Context) ([]ClarityCode, error) {
+	var codes []ClarityCode
+	if err := c.doRequest(ctx, http.MethodGet, "claritycode", nil, &codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// GetBillingTickets reads the given tickets, all tickets booked on the given
+// clarity codes and all tickets billed to the given subscriptions, each ticket
+// once, including their billing items
+func (c *CloudportalAPIClient) GetBillingTickets(ctx context.Context, ticketIDs, clarityCodes, subscriptions []string) ([]Ticket, error) {
+	for _, code := range clarityCodes {
+		filter := url.Values{}
+		filter.Set("claritycode", code)
+
+		listed, err := c.ListTickets(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("error listing tickets for clarity code %s: %s", code, err)
+		}
+		for _, ticket := range listed {
+			ticketIDs = append(ticketIDs, ticket.ID)
+		}
+	}
+
+	for _, subscription := range subscriptions {
+		filter := url.Values{}
+		filter.Set("subscriptionname", subscription)
+
+		listed, err := c.ListTickets(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("error listing tickets for subscription %s: %s", subscription, err)
+		}
+		for _, ticket := range listed {
+			ticketIDs = append(ticketIDs, ticket.ID)
+		}
+	}
+
+	// The list endpoint does not return billing items, so read every ticket
+	seen := make(map[string]bool)
+	var tickets []Ticket
+	for _, id := range ticketIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		ticket, err := c.GetTicket(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ticket %s: %s", id, err)
+		}
+		tickets = append(tickets, *ticket)
+	}
+
+	return tickets, nil
+}
@@ -0,0 +1,151 @@
+package cloudportal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+)
+
+// RateLimitConfig caps the load a client puts on the portal API. Zero values
+// disable the respective limit.
+type RateLimitConfig struct {
+	MaxConcurrentRequests int     // Requests in flight at the same time
+	RequestsPerSecond     float64 // Sustained request rate, bursts of up to one second of requests are allowed
+}
+
+// rateLimiter is a token bucket combined with a semaphore. It is shared by all
+// calls of a client, including retries.
+type rateLimiter struct {
+	slots chan struct{}
+	rate  float64
+	burst float64
+
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	requests int
+	waited   time.Duration
+}
+
+// newRateLimiter creates a limiter for the config
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{rate: config.RequestsPerSecond}
+	if config.MaxConcurrentRequests > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	if l.rate > 0 {
+		l.burst = math.Max(1, math.Floor(l.rate))
+		l.tokens = l.burst
+		l.last = time.Now()
+	}
+	return l
+}
+
+// acquire blocks until the request may be sent and returns how long it waited.
+// release must be called once the request is done if acquire succeeded.
+func (l *rateLimiter) acquire(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	if l.rate > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		l.tokens--
+		wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				l.mu.Lock()
+				l.tokens++
+				l.mu.Unlock()
+				l.release()
+				return 0, ctx.Err()
+			}
+		}
+	}
+
+	waited := time.Since(start)
+	l.mu.Lock()
+	l.requests++
+	l.waited += waited
+	l.mu.Unlock()
+
+	return waited, nil
+}
+
+// release frees the concurrency slot taken by acquire
+func (l *rateLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// stats returns the number of requests and the total time spent waiting
+func (l *rateLimiter) stats() (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.requests, l.waited
+}
+
+// rateLimitTransport sends requests through the rate limiter. The concurrency
+// slot is held until the response body is closed.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := t.limiter
+
+	waited, err := limiter.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if waited >= time.Millisecond {
+		requests, total := limiter.stats()
+		logger.Debug(fmt.Sprintf("Rate limit: waited %s for %s %s, %s in total over %d requests", waited.Round(time.Millisecond), req.Method, req.URL.Path, total.Round(time.Millisecond), requests))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		limiter.release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: limiter.release}
+	return resp, nil
+}
+
+// releaseOnClose calls release once when the body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close implements io.Closer
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,83 @@
+package cloudportal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRate(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   RateLimitConfig
+		requests int
+		min, max time.Duration
+	}{
+		{"unlimited", RateLimitConfig{}, 20, 0, 50 * time.Millisecond},
+		{"burst is not delayed", RateLimitConfig{RequestsPerSecond: 20}, 20, 0, 50 * time.Millisecond},
+		{"requests beyond the burst wait", RateLimitConfig{RequestsPerSecond: 20}, 25, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"fractional rate allows one request", RateLimitConfig{RequestsPerSecond: 0.5}, 1, 0, 50 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := newRateLimiter(tc.config)
+			start := time.Now()
+			for i := 0; i < tc.requests; i++ {
+				if _, err := limiter.acquire(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				limiter.release()
+			}
+			if elapsed := time.Since(start); elapsed < tc.min || elapsed > tc.max {
+				t.Errorf("%d requests took %s, want between %s and %s", tc.requests, elapsed, tc.min, tc.max)
+			}
+			if requests, _ := limiter.stats(); requests != tc.requests {
+				t.Errorf("stats counted %d requests, want %d", requests, tc.requests)
+			}
+		})
+	}
+}
+
+func TestRateLimiterConcurrency(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{MaxConcurrentRequests: 2})
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A third request waits for a free slot and gives up with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	limiter.release()
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatalf("slot was not released: %s", err)
+	}
+}
+
+func TestRateLimiterCancelReturnsToken(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{RequestsPerSecond: 1, MaxConcurrentRequests: 1})
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	limiter.release()
+
+	// The bucket is empty, the request is cancelled while waiting for a token
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The cancelled request gave back its concurrency slot
+	select {
+	case limiter.slots <- struct{}{}:
+	default:
+		t.Error("concurrency slot was not released")
+	}
+}
@@ -0,0 +1,138 @@
+package cloudportal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+)
+
+// RetryConfig controls how often and how long failed requests are retried
+type RetryConfig struct {
+	MaxRetries int           // Retries after the first attempt, 0 disables retries
+	MinWait    time.Duration // Wait before the first retry, doubled for every further retry
+	MaxWait    time.Duration // Upper bound of the backoff between retries
+}
+
+// DefaultRetryConfig is used by clients created with NewCloudportalAPIClient
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries: 3,
+	MinWait:    time.Second,
+	MaxWait:    30 * time.Second,
+}
+
+// retryTransport retries idempotent requests on network errors, 429 and 5xx
+// responses with jittered exponential backoff, honouring Retry-After
+type retryTransport struct {
+	base   http.RoundTripper
+	config RetryConfig
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.config.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		// A request body can only be sent again if it can be recreated
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if err != nil {
+			logger.Debug(fmt.Sprintf("Retrying %s %s in %s after error: %s", req.Method, req.URL.Path, wait, err))
+		} else {
+			logger.Debug(fmt.Sprintf("Retrying %s %s in %s after status %s", req.Method, req.URL.Path, wait, resp.Status))
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the wait before the next retry. The exponential backoff is
+// jittered between half and its full value, a longer Retry-After wins.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	wait := t.config.MinWait << attempt
+	if wait > t.config.MaxWait || wait <= 0 {
+		wait = t.config.MaxWait
+	}
+	if wait > 0 {
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	}
+
+	if resp != nil {
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > wait {
+			wait = retryAfter
+		}
+	}
+	return wait
+}
+
+// idempotentKey is the context key set by withIdempotent
+type idempotentKey struct{}
+
+// withIdempotent marks the requests made with the returned context as safe to
+// retry whatever their method, e.g. a POST that only reads
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent reports whether the request can safely be sent more than once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// shouldRetry reports whether the outcome of an attempt is transient
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
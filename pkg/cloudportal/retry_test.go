@@ -0,0 +1,237 @@
+package cloudportal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// statusSequence answers the requests with the statuses in order, the last
+// one repeats, and counts the attempts
+func statusSequence(attempts *int, statuses ...int) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		status := statuses[len(statuses)-1]
+		if *attempts < len(statuses) {
+			status = statuses[*attempts]
+		}
+		*attempts++
+		if req.Body != nil {
+			io.Copy(io.Discard, req.Body)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := &retryTransport{config: RetryConfig{MinWait: time.Second, MaxWait: 10 * time.Second}}
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	cases := []struct {
+		name     string
+		attempt  int
+		resp     *http.Response
+		min, max time.Duration
+	}{
+		{"first retry", 0, nil, 500 * time.Millisecond, time.Second},
+		{"doubled", 2, nil, 2 * time.Second, 4 * time.Second},
+		{"capped at max wait", 5, nil, 5 * time.Second, 10 * time.Second},
+		{"overflowing shift is capped", 70, nil, 5 * time.Second, 10 * time.Second},
+		{"longer Retry-After wins", 0, retryAfter("20"), 20 * time.Second, 20 * time.Second},
+		{"shorter Retry-After is ignored", 2, retryAfter("1"), 2 * time.Second, 4 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				if wait := transport.backoff(tc.attempt, tc.resp); wait < tc.min || wait > tc.max {
+					t.Fatalf("backoff = %s, want between %s and %s", wait, tc.min, tc.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "7", 7 * time.Second, 7 * time.Second},
+		{"zero seconds", "0", 0, 0},
+		{"negative seconds", "-3", 0, 0},
+		{"http date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{"date in the past", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), -2 * time.Minute, 0},
+		{"garbage", "soon", 0, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value); got < tc.min || got > tc.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tc.value, got, tc.min, tc.max)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRoundTrip(t *testing.T) {
+	config := RetryConfig{MaxRetries: 2, MinWait: time.Millisecond, MaxWait: time.Millisecond}
+
+	cases := []struct {
+		name         string
+		method       string
+		body         func() io.Reader
+		noGetBody    bool
+		idempotent   bool
+		statuses     []int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "success", method: http.MethodGet, statuses: []int{200}, wantStatus: 200, wantAttempts: 1},
+		{name: "transient then success", method: http.MethodGet, statuses: []int{503, 429, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "retries exhausted", method: http.MethodGet, statuses: []int{502}, wantStatus: 502, wantAttempts: 3},
+		{name: "client errors are final", method: http.MethodGet, statuses: []int{404}, wantStatus: 404, wantAttempts: 1},
+		{name: "post is not retried", method: http.MethodPost, statuses: []int{503, 200}, wantStatus: 503, wantAttempts: 1},
+		{
+			name:         "post marked idempotent is retried",
+			method:       http.MethodPost,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			idempotent:   true,
+			statuses:     []int{503, 200},
+			wantStatus:   200,
+			wantAttempts: 2,
+		},
+		{
+			name:         "put body is recreated",
+			method:       http.MethodPut,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			statuses:     []int{503, 200},
+			wantStatus:   200,
+			wantAttempts: 2,
+		},
+		{
+			name:         "body that cannot be recreated",
+			method:       http.MethodPut,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			noGetBody:    true,
+			statuses:     []int{503, 200},
+			wantStatus:   503,
+			wantAttempts: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader
+			if tc.body != nil {
+				body = tc.body()
+			}
+			ctx := context.Background()
+			if tc.idempotent {
+				ctx = withIdempotent(ctx)
+			}
+			req, err := http.NewRequestWithContext(ctx, tc.method, "https://portal.example.com/ticket", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.noGetBody {
+				req.GetBody = nil
+			}
+
+			attempts := 0
+			transport := &retryTransport{base: statusSequence(&attempts, tc.statuses...), config: config}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.wantStatus)
+			}
+			if attempts != tc.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+// staticCredential hands out a fixed access token
+type staticCredential struct{}
+
+func (staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestLookupCatalogFieldRetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[{"value":"westeurope","label":"West Europe"}]`)
+	}))
+	defer server.Close()
+
+	client := NewCloudportalAPIClient(staticCredential{}, "key", server.URL, []string{"api://portal/.default"}, false)
+	client.SetRetryConfig(RetryConfig{MaxRetries: 2, MinWait: time.Millisecond, MaxWait: time.Millisecond})
+
+	values, err := client.LookupCatalogField(context.Background(), "regions", map[string]string{"subscription": "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0].Value != "westeurope" {
+		t.Errorf("values = %v", values)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://portal.example.com/ticket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+	transport := &retryTransport{
+		base:   statusSequence(&attempts, 503),
+		config: RetryConfig{MaxRetries: 5, MinWait: time.Hour, MaxWait: time.Hour},
+	}
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled retry returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,288 @@
+package cloudportal
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/terraform-provider-cloudportal/cloudportal/internal/logger"
+)
+
+const (
+	// tokenExpiryMargin is how long before ExpiresOn a token is no longer used
+	tokenExpiryMargin = 2 * time.Minute
+	// tokenRefreshMargin is how long before ExpiresOn a new token is fetched in
+	// the background while the cached one is still handed out
+	tokenRefreshMargin = 10 * time.Minute
+)
+
+// The token cache file starts with tokenCacheMagic and a random salt of
+// tokenCacheSaltSize bytes, the encryption key is derived from the passphrase
+// and the salt with scrypt
+const (
+	tokenCacheMagic    = "CPTC1"
+	tokenCacheSaltSize = 16
+	scryptN            = 1 << 15
+	scryptR            = 8
+	scryptP            = 1
+)
+
+// tokenCache hands out access tokens for a fixed set of scopes and only asks
+// the credential for a new one when the cached token is about to expire. It is
+// safe for concurrent use by the parallel graph walk of Terraform.
+type tokenCache struct {
+	credential azcore.TokenCredential
+	scopes     []string
+	file       *tokenCacheFile
+	timeout    time.Duration // Limits every token request, 0 disables the limit
+
+	mu         sync.Mutex
+	token      azcore.AccessToken
+	refreshing bool
+}
+
+// newTokenCache creates an in-memory token cache, file may be nil
+func newTokenCache(credential azcore.TokenCredential, scopes []string, file *tokenCacheFile) *tokenCache {
+	cache := &tokenCache{
+		credential: credential,
+		scopes:     scopes,
+		file:       file,
+	}
+	if file != nil {
+		if token, ok := file.load(); ok {
+			cache.token = token
+		}
+	}
+	return cache
+}
+
+// Token returns a valid access token, fetching a new one when needed
+func (c *tokenCache) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token.Token != "" && now.Add(tokenExpiryMargin).Before(c.token.ExpiresOn) {
+		if !c.refreshing && !now.Add(tokenRefreshMargin).Before(c.token.ExpiresOn) {
+			c.refreshing = true
+			go c.refresh()
+		}
+		return c.token.Token, nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	token, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: c.scopes})
+	if err != nil {
+		return "", err
+	}
+	c.store(token)
+
+	return token.Token, nil
+}
+
+// refresh fetches a new token ahead of expiry, failures are logged and the
+// next call of Token retries in the foreground once the old token runs out
+func (c *tokenCache) refresh() {
+	ctx, cancel := c.withTimeout(context.Background())
+	defer cancel()
+	token, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: c.scopes})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	if err != nil {
+		logger.Error("background token refresh failed: " + err.Error())
+		return
+	}
+	c.store(token)
+}
+
+// withTimeout limits a token request to the timeout of the cache
+func (c *tokenCache) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// store keeps the token in memory and in the cache file, the caller holds mu
+func (c *tokenCache) store(token azcore.AccessToken) {
+	c.token = token
+	logger.Debug("Token refreshed, expires on " + token.ExpiresOn.Format(time.RFC3339))
+	if c.file != nil {
+		if err := c.file.save(token); err != nil {
+			logger.Error("error writing token cache: " + err.Error())
+		}
+	}
+}
+
+// tokenCacheFile persists tokens between runs in a file encrypted with
+// AES-GCM. Tokens of several identities and scopes share one file, each
+// stored under its own entry.
+type tokenCacheFile struct {
+	path       string
+	passphrase string
+	entry      string
+
+	// The key derived for salt, kept as scrypt is deliberately slow
+	salt []byte
+	key  []byte
+}
+
+// cachedToken is the persisted form of an access token
+type cachedToken struct {
+	Token     string    `json:"token"`
+	ExpiresOn time.Time `json:"expireson"`
+}
+
+// newTokenCacheFile returns the token cache file at path, encrypted with a key
+// derived from passphrase. identity distinguishes the principals sharing the
+// file, e.g. the auth method, tenant and client id.
+func newTokenCacheFile(path, passphrase string, identity []string, scopes []string) *tokenCacheFile {
+	entry := sha256.Sum256([]byte(strings.Join(identity, "\x00") + "\x00" + strings.Join(scopes, " ")))
+	return &tokenCacheFile{
+		path:       path,
+		passphrase: passphrase,
+		entry:      hex.EncodeToString(entry[:]),
+	}
+}
+
+// load returns the cached token of the entry if it has not expired yet
+func (f *tokenCacheFile) load() (azcore.AccessToken, bool) {
+	entries, err := f.read()
+	if err != nil {
+		logger.Error("error reading token cache: " + err.Error())
+		return azcore.AccessToken{}, false
+	}
+
+	cached, ok := entries[f.entry]
+	if !ok || !time.Now().Add(tokenExpiryMargin).Before(cached.ExpiresOn) {
+		return azcore.AccessToken{}, false
+	}
+	return azcore.AccessToken{Token: cached.Token, ExpiresOn: cached.ExpiresOn}, true
+}
+
+// save stores the token under the entry and drops expired entries
+func (f *tokenCacheFile) save(token azcore.AccessToken) error {
+	entries, err := f.read()
+	if err != nil {
+		// An unreadable file, e.g. encrypted with another key, is replaced
+		logger.Error("error reading token cache: " + err.Error())
+		entries = map[string]cachedToken{}
+	}
+
+	now := time.Now()
+	for k, v := range entries {
+		if !now.Before(v.ExpiresOn) {
+			delete(entries, k)
+		}
+	}
+	entries[f.entry] = cachedToken{Token: token.Token, ExpiresOn: token.ExpiresOn}
+
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	// Every write uses a fresh salt
+	salt := make([]byte, tokenCacheSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := f.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header := append([]byte(tokenCacheMagic), salt...)
+	data := gcm.Seal(append(header, nonce...), nonce, plaintext, header)
+
+	// Write to a temporary file first so concurrent runs never see a partial file
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// read decrypts all entries of the file, a missing file has no entries
+func (f *tokenCacheFile) read() (map[string]cachedToken, error) {
+	entries := map[string]cachedToken{}
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := len(tokenCacheMagic) + tokenCacheSaltSize
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte(tokenCacheMagic)) {
+		return nil, fmt.Errorf("token cache %s has an unknown format", f.path)
+	}
+	header, data := data[:headerSize], data[headerSize:]
+
+	gcm, err := f.cipher(header[len(tokenCacheMagic):])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("token cache %s is corrupt", f.path)
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting token cache %s: %s", f.path, err)
+	}
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding token cache %s: %s", f.path, err)
+	}
+	return entries, nil
+}
+
+// cipher returns the AES-GCM cipher of the key derived for salt
+func (f *tokenCacheFile) cipher(salt []byte) (cipher.AEAD, error) {
+	if f.key == nil || !bytes.Equal(f.salt, salt) {
+		key, err := scrypt.Key([]byte(f.passphrase), salt, scryptN, scryptR, scryptP, 32)
+		if err != nil {
+			return nil, err
+		}
+		f.salt = append([]byte(nil), salt...)
+		f.key = key
+	}
+
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,107 @@
+package cloudportal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// hangingCredential blocks every token request until its context is done
+type hangingCredential struct{}
+
+func (hangingCredential) GetToken(ctx context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	<-ctx.Done()
+	return azcore.AccessToken{}, ctx.Err()
+}
+
+func TestTokenCacheTimeout(t *testing.T) {
+	cache := newTokenCache(hangingCredential{}, []string{"api://portal/.default"}, nil)
+	cache.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := cache.Token(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("token request took %s", elapsed)
+	}
+}
+
+func TestTokenCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	scopes := []string{"api://portal/.default"}
+	alice := newTokenCacheFile(path, "secret", []string{"client_secret", "tenant", "alice"}, scopes)
+	bob := newTokenCacheFile(path, "secret", []string{"client_secret", "tenant", "bob"}, scopes)
+	expiresOn := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if _, ok := alice.load(); ok {
+		t.Fatal("missing file returned a token")
+	}
+	if err := alice.save(azcore.AccessToken{Token: "a", ExpiresOn: expiresOn}); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(path)
+	if err := bob.save(azcore.AccessToken{Token: "b", ExpiresOn: expiresOn}); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(path)
+
+	cases := []struct {
+		name string
+		file *tokenCacheFile
+		want string
+	}{
+		{"first identity", newTokenCacheFile(path, "secret", []string{"client_secret", "tenant", "alice"}, scopes), "a"},
+		{"second identity", newTokenCacheFile(path, "secret", []string{"client_secret", "tenant", "bob"}, scopes), "b"},
+		{"other scopes", newTokenCacheFile(path, "secret", []string{"client_secret", "tenant", "alice"}, []string{"other"}), ""},
+		{"wrong passphrase", newTokenCacheFile(path, "guess", []string{"client_secret", "tenant", "alice"}, scopes), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, ok := tc.file.load()
+			if ok != (tc.want != "") || token.Token != tc.want {
+				t.Errorf("load = %q, %t, want %q", token.Token, ok, tc.want)
+			}
+		})
+	}
+
+	if !bytes.HasPrefix(second, []byte(tokenCacheMagic)) {
+		t.Error("file does not start with the format header")
+	}
+	saltOf := func(data []byte) []byte {
+		return data[len(tokenCacheMagic) : len(tokenCacheMagic)+tokenCacheSaltSize]
+	}
+	if bytes.Equal(saltOf(first), saltOf(second)) {
+		t.Error("salt was reused between writes")
+	}
+	if bytes.Contains(second, []byte(`"token"`)) {
+		t.Error("file is not encrypted")
+	}
+}
+
+func TestTokenCacheFileReplacesUnreadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	// A file written with the former unsalted format
+	if err := os.WriteFile(path, []byte("0123456789abcdef-legacy"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTokenCacheFile(path, "secret", []string{"client_secret"}, nil)
+	if _, ok := f.load(); ok {
+		t.Fatal("unreadable file returned a token")
+	}
+	if err := f.save(azcore.AccessToken{Token: "a", ExpiresOn: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if token, ok := f.load(); !ok || token.Token != "a" {
+		t.Errorf("load = %q, %t", token.Token, ok)
+	}
+}
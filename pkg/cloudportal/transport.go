@@ -0,0 +1,100 @@
+package cloudportal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TLSVersions maps the supported minimum TLS versions to their tls constants
+var TLSVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// linkLocalNoProxy is always reached without proxy, the managed identity
+// endpoint (169.254.169.254) is only reachable from the host itself
+const linkLocalNoProxy = "169.254.0.0/16,fe80::/10"
+
+// TransportConfig holds the network settings used for portal API and token
+// requests. Zero values keep the defaults of net/http.
+type TransportConfig struct {
+	ProxyURL       string // Proxy for all requests, defaults to HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	NoProxy        string // Comma separated hosts, domains and CIDRs reached without proxy, ignored without ProxyURL
+	CACertFile     string // PEM file of additional trusted root certificates
+	CACertPEM      string // PEM encoded additional trusted root certificates
+	ClientCertFile string // PEM client certificate presented for mutual TLS
+	ClientKeyFile  string // PEM private key of the client certificate
+	MinTLSVersion  string // Minimum TLS version, one of TLSVersions, defaults to 1.2
+}
+
+// NewTransport builds the HTTP transport for the config
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxyConfig := httpproxy.FromEnvironment()
+	if config.ProxyURL != "" {
+		if _, err := url.Parse(config.ProxyURL); err != nil {
+			return nil, fmt.Errorf("error parsing proxy URL: %s", err)
+		}
+		proxyConfig = &httpproxy.Config{
+			HTTPProxy:  config.ProxyURL,
+			HTTPSProxy: config.ProxyURL,
+			NoProxy:    config.NoProxy,
+		}
+	}
+	if proxyConfig.NoProxy != "" {
+		proxyConfig.NoProxy += ","
+	}
+	proxyConfig.NoProxy += linkLocalNoProxy
+	proxy := proxyConfig.ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.MinTLSVersion != "" {
+		version, ok := TLSVersions[config.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", config.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if config.CACertFile != "" || config.CACertPEM != "" {
+		// Private roots are trusted in addition to the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if config.CACertFile != "" {
+			data, err := os.ReadFile(config.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("error reading CA certificates: %s", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in %s", config.CACertFile)
+			}
+		}
+		if config.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(config.CACertPEM)) {
+			return nil, fmt.Errorf("no certificates found in CA certificate PEM")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
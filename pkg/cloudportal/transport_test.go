@@ -0,0 +1,50 @@
+package cloudportal
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewTransportProxy(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+	t.Setenv("HTTP_PROXY", "http://env-proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	cases := []struct {
+		name   string
+		config TransportConfig
+		url    string
+		want   string
+	}{
+		{"configured proxy", TransportConfig{ProxyURL: "http://proxy.example.com:8080"}, "https://portal.example.com/api", "http://proxy.example.com:8080"},
+		{"configured no_proxy", TransportConfig{ProxyURL: "http://proxy.example.com:8080", NoProxy: ".example.com"}, "https://portal.example.com/api", ""},
+		{"managed identity endpoint", TransportConfig{ProxyURL: "http://proxy.example.com:8080", NoProxy: ".example.com"}, "http://169.254.169.254/metadata/identity/oauth2/token", ""},
+		{"ipv6 link-local", TransportConfig{ProxyURL: "http://proxy.example.com:8080"}, "http://[fe80::1]/metadata", ""},
+		{"environment proxy", TransportConfig{NoProxy: "portal.example.com"}, "https://portal.example.com/api", "http://env-proxy.example.com:3128"},
+		{"environment no_proxy", TransportConfig{}, "https://internal.example.com/api", ""},
+		{"managed identity endpoint with environment proxy", TransportConfig{}, "http://169.254.169.254/metadata/identity/oauth2/token", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, err := NewTransport(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy, err := transport.Proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if proxy != nil {
+				got = proxy.String()
+			}
+			if got != tc.want {
+				t.Errorf("proxy = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package cloudportal
+
+type Ticket struct {
+	ID                  string        `json:"id"`                  // Unique identifier for the ticket.
+	TicketNo            int           `json:"ticketno"`            // Ticket number.
+	Title               string        `json:"title"`               // Ticket title.
+	Description         string        `json:"description"`         // Ticket description.
+	Status              string        `json:"status"`              // Current status of the ticket.
+	SubStatus           string        `json:"substatus"`           // Sub-status of the ticket.
+	StatusChangedAt     string        `json:"statuschangedat"`     // Timestamp when the status was last changed.
+	CreatedAt           string        `json:"createdat"`           // Timestamp when the ticket was created.
+	CreatedBy           User          `json:"createdby"`           // Details of the user who created the ticket.
+	ChangedBy           User          `json:"changedby"`           // Details of the user who last changed the ticket.
+	ClarityCode         ClarityCode   `json:"claritycode"`         // Clarity code details.
+	Participants        []Participant `json:"participants"`        // List of participants in the ticket.
+	Comments            []Comment     `json:"comments"`            // List of comments on the ticket.
+	Attachments         []Attachment  `json:"attachments"`         // List of attachments for the ticket.
+	BillingItems        []BillingItem `json:"billingitems"`        // List of billing items related to the ticket.
+	HistoryItems        []HistoryItem `json:"historyitems"`        // History of changes to the ticket.
+	ValidActions        []Action      `json:"validactions"`        // List of valid actions that can be performed on the ticket.
+	EditableProperties  []string      `json:"editableproperties"`  // List of editable properties of the ticket.
+	MandatoryProperties []string      `json:"mandatoryproperties"` // List of mandatory properties for the ticket.
+	ETag                string        `json:"etag"`                // ETag for the ticket.
+	Type                string        `json:"type"`                // Ticket type.
+	ServiceProvider     string        `json:"serviceprovider"`     // Service provider name.
+	CloudPlatform       string        `json:"cloudplatform"`       // Cloud platform for the ticket.
+	CatalogItems        []CatalogItem `json:"catalogitems"`        // Catalog items associated with the ticket.
+}
+
+type User struct {
+	ID                string   `json:"id"`
+	Email             string   `json:"email"`
+	UserPrincipalName string   `json:"userprincipalname"`
+	DisplayName       string   `json:"displayname"`
+	Roles             []string `json:"roles"`
+}
+
+type Participant struct {
+	UserInfo User   `json:"userinfo"`
+	Role     string `json:"role"`
+}
+
+type Comment struct {
+	ID          string `json:"id"`
+	Createdat   string `json:"createdat"`
+	Modifiedat  string `json:"modifiedat"`
+	Author      User   `json:"author"`
+	Content     string `json:"content"`
+	Loginuser   User   `json:"loginuser"`
+	Iseditable  bool   `json:"iseditable"`
+	Iseditmode  bool   `json:"IsEditMode"`
+	Contentcopy string `json:"contentcopy"`
+}
+
+type Action struct {
+	ActionName           string   `json:"actionname"`
+	RequiredProperties   []string `json:"requiredproperties"`
+	Type                 string   `json:"type"`
+	MinNumOfCatalogItems int      `json:"minnumofcatalogitems"`
+}
+
+// InvoicePeriod represents a billing period and related details.
+type InvoicePeriod struct {
+	InvoicePeriod string  `json:"invoiceperiod"`
+	ActualCost    float64 `json:"actualcost"`
+	StartDate     string  `json:"startdate"`
+	EndDate       string  `json:"enddate"`
+}
+
+// BillingItem represents a billing item with associated metadata.
+type BillingItem struct {
+	ID               string                   `json:"id"`
+	PartitionKey     string                   `json:"partitionkey"`
+	SubscriptionName string                   `json:"subscriptionname"`
+	InvoicePeriods   map[string]InvoicePeriod `json:"invoiceperiods"`
+}
+
+// Change represents a single modification or update made to a ticket.
+type Change struct {
+	PropertyName string            `json:"propertyname"` // The name of the property that was changed (e.g., "status").
+	OldValue     map[string]string `json:"oldvalue"`     // The old value of the property (before change).
+	NewValue     map[string]string `json:"newvalue"`     // The new value of the property (after change).
+}
+
+type HistoryItem struct {
+	Date    string   `json:"date"`    // The date when the history item was created.
+	Author  []User   `json:"author"`  // The user who made the change.
+	Changes []Change `json:"changes"` // List of changes that were made in this history item.
+}
+
+// CatalogItem represents a catalog item with all associated metadata.
+type CatalogItem struct {
+	Name                     string            `json:"name"`
+	ResourceName             string            `json:"resourcename"`
+	Label                    string            `json:"label"`
+	CatalogItemDisclaimer    *string           `json:"catalogitemdisclaimer,omitempty"`
+	CatalogItemCloudPlatform string            `json:"catalogitemcloudplatform"`
+	TicketTypes              []string          `json:"tickettypes"`
+	Active                   bool              `json:"active"`
+	CatalogItemVersion       int               `json:"catalogitemversion"`
+	CatalogItemCreated       string            `json:"catalogitemcreated"`
+	CatalogItemApproved      string            `json:"catalogitemapproved"`
+	CatalogItemApprovedBy    string            `json:"catalogitemapprovedby"`
+	CatalogItemIcon          *string           `json:"catalogitemicon,omitempty"`
+	CatalogFields            []CatalogField    `json:"catalogfields"`
+	Variables                map[string]string `json:"variables"`
+	ResourceContractName     *string           `json:"resourcecontractname,omitempty"`
+	ResourceContainerName    *string           `json:"resourcecontainername,omitempty"`
+}
+
+// CatalogField represents a field in a catalog item with various attributes.
+type CatalogField struct {
+	Key            string   `json:"key"`
+	Label          string   `json:"label"`
+	Value          string   `json:"value"`
+	IsMandatory    bool     `json:"ismandatory"`
+	LookupFunction *string  `json:"lookupfunction,omitempty"`
+	LookupValues   []string `json:"lookupvalues,omitempty"`
+	HintValue      *string  `json:"hintvalue,omitempty"`
+	InputType      *string  `json:"inputType,omitempty"`
+	InputFormat    *string  `json:"inputformat,omitempty"`
+	EnableToggleBy *string  `json:"enabletoggleby,omitempty"`
+	Disabled       *string  `json:"disabled,omitempty"`
+}
+
+// Attachment represents the details of an attachment with metadata.
+type Attachment struct {
+	URL            string `json:"url"`
+	UploadDateTime string `json:"uploaddatetime"`
+	UploadedBy     []User `json:"uploadedby"`
+	Filename       string `json:"filename"`
+}
+
+type ClarityCode struct {
+	Code        string   `json:"code"`        // Clarity code
+	Description string   `json:"description"` // Description of the clarity code
+	CostCenter  string   `json:"costcenter"`  // Cost center for the clarity code
+	Emails      []string `json:"emails"`      // List of emails related to the clarity code
+	Tower       string   `json:"tower"`       // Tower associated with the clarity code
+}